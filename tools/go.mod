@@ -4,5 +4,6 @@ go 1.21
 
 require (
 	github.com/google/go-cmp v0.6.0
+	golang.org/x/net v0.26.0
 	golang.org/x/text v0.16.0
 )
@@ -3,17 +3,30 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"github.com/publicsuffix/list/tools/internal/parser"
 )
 
 func main() {
 	warnings := flag.Bool("with-warnings", false, "also print errors that were downgraded to warnings")
+	jsonOutput := flag.Bool("json", false, "print errors as a JSON report")
+	summary := flag.Bool("summary", false, "print a JSON summary of the file's validations by category, with how many ran and passed, and their findings")
+	sarif := flag.Bool("sarif", false, "print errors as a SARIF 2.1.0 log, for code scanning tools")
+	annotations := flag.Bool("github-annotations", false, "print errors as GitHub Actions workflow commands, to annotate files in pull requests")
+	changedSince := flag.String("changed-since", "", "only report errors about blocks that differ from this older, trusted PSL file, check the changes from it, and use its ICANN section for validation")
+	blocklist := flag.String("blocklist", "", "reject added or changed suffixes that are, or are under, a domain in this file, which lists one domain per line and # comments")
+	grouped := flag.Bool("group-by-block", false, "print errors grouped by the block of suffixes they are about")
+	danglingErrors := flag.Bool("dangling-exception-errors", false, "with -changed-since, report exceptions left behind by removed suffixes as errors rather than warnings")
+	unicode := flag.Bool("unicode", false, "show the Unicode form of punycode domains in error messages")
+	maxLineLength := flag.Int("max-line-length", parser.DefaultMaxLineLength, "warn about lines longer than this many characters, 0 to disable")
 	flag.Usage = func() {
-		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] pslfile\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] pslfile\n\nIf pslfile is -, the PSL is read from stdin.\n\n", os.Args[0])
 		flag.PrintDefaults()
 	}
 	flag.Parse()
@@ -24,20 +37,146 @@ func main() {
 
 	file := flag.Arg(0)
 
-	bs, err := os.ReadFile(file)
+	// "-" reads the PSL from stdin. There is no path to report in
+	// that case, so errors only mention line numbers.
+	var in io.Reader = os.Stdin
+	path := ""
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read PSL file: %v", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		in = f
+		path = file
+	}
+
+	bs, err := io.ReadAll(in)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to read PSL file: %v", err)
 		os.Exit(1)
 	}
 
-	psl := parser.Parse(bs)
+	// The base file is trusted, so its ICANN section is used to
+	// validate the new file, rather than the possibly edited one.
+	var base *parser.File
+	if *changedSince != "" {
+		baseBytes, err := os.ReadFile(*changedSince)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read base PSL file: %v", err)
+			os.Exit(1)
+		}
+		base = parser.Parse(baseBytes)
+	}
 
-	for _, err := range psl.Errors {
-		fmt.Println(err)
+	var blocked []string
+	if *blocklist != "" {
+		blocked, err = readBlocklist(*blocklist)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read blocklist: %v", err)
+			os.Exit(1)
+		}
+	}
+
+	opts := parser.Options{
+		Reference:               base,
+		MaxLineLength:           *maxLineLength,
+		DanglingExceptionErrors: *danglingErrors,
+	}
+	if opts.MaxLineLength == 0 {
+		opts.MaxLineLength = -1
+	}
+	psl, validated := validate(bs, path, base, blocked, opts)
+
+	if *summary {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(validated); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write validation summary: %v", err)
+			os.Exit(1)
+		}
+		if len(psl.Errors) > 0 {
+			os.Exit(1)
+		}
+		return
 	}
-	if *warnings {
-		for _, err := range psl.Warnings {
-			fmt.Println(err, "(warning)")
+
+	if *jsonOutput {
+		report := struct {
+			Errors   []parser.ErrorInfo `json:"errors"`
+			Warnings []parser.ErrorInfo `json:"warnings,omitempty"`
+		}{
+			Errors: parser.Describe(path, psl.Errors),
+		}
+		if *warnings {
+			report.Warnings = parser.Describe(path, psl.Warnings)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write JSON report: %v", err)
+			os.Exit(1)
+		}
+		if len(psl.Errors) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *sarif {
+		var warningInfos []parser.ErrorInfo
+		if *warnings {
+			warningInfos = parser.Describe(path, psl.Warnings)
+		}
+		if err := parser.WriteSARIF(os.Stdout, parser.Describe(path, psl.Errors), warningInfos); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write SARIF log: %v", err)
+			os.Exit(1)
+		}
+		if len(psl.Errors) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	message := func(msg string) string {
+		if *unicode {
+			return parser.WithUnicodeDomains(msg)
+		}
+		return msg
+	}
+	if *annotations {
+		for _, info := range parser.Describe(path, psl.Errors) {
+			info.Message = message(info.Message)
+			fmt.Println(info.GitHubAnnotation("error"))
+		}
+		if *warnings {
+			for _, info := range parser.Describe(path, psl.Warnings) {
+				info.Message = message(info.Message)
+				fmt.Println(info.GitHubAnnotation("warning"))
+			}
+		}
+	} else if *grouped {
+		printGroups := func(errs []error, note string) {
+			for _, g := range parser.GroupByBlock(psl, errs) {
+				fmt.Println(message(g.Heading()) + note)
+				for _, err := range g.Errors {
+					fmt.Println("  " + message(err.Error()))
+				}
+			}
+		}
+		printGroups(psl.Errors, "")
+		if *warnings {
+			printGroups(psl.Warnings, " (warnings)")
+		}
+	} else {
+		for _, err := range psl.Errors {
+			fmt.Println(message(err.Error()))
+		}
+		if *warnings {
+			for _, err := range psl.Warnings {
+				fmt.Println(message(err.Error()), "(warning)")
+			}
 		}
 	}
 	if len(psl.Errors) > 0 {
@@ -46,3 +185,71 @@ func main() {
 		fmt.Printf("%q seems to be a valid PSL file.\n", file)
 	}
 }
+
+// validate parses and validates bs, which was read from path, and
+// returns the result along with a summary of all the checks that ran.
+//
+// If base is not nil, only errors about the changes from base are
+// kept, and the changes themselves are checked. If blocked is not
+// nil, added or changed suffixes are checked against it. The findings
+// of these checks are in the "changes" category of the summary, like
+// they are in the Errors and Warnings of the returned File, so that
+// the summary only passes if the file does.
+func validate(bs []byte, path string, base *parser.File, blocked []string, opts parser.Options) (*parser.File, parser.ValidationSummary) {
+	psl, validated := parser.ParseWithSummary(bs, path, opts)
+
+	if base != nil {
+		psl.Errors = parser.OnlyChanged(psl.Errors, base, psl)
+		psl.Warnings = parser.OnlyChanged(psl.Warnings, base, psl)
+		for i, check := range validated.Checks {
+			validated.Checks[i].Errors = parser.OnlyChanged(check.Errors, base, psl)
+			validated.Checks[i].Warnings = parser.OnlyChanged(check.Warnings, base, psl)
+		}
+
+		errs, warnings := parser.ValidateChanges(base, psl, opts)
+		psl.Errors = append(psl.Errors, errs...)
+		psl.Warnings = append(psl.Warnings, warnings...)
+		validated.Checks = append(validated.Checks, parser.CheckResult{
+			Name:     "changes",
+			Category: "changes",
+			Errors:   errs,
+			Warnings: warnings,
+		})
+	}
+
+	if blocked != nil {
+		// Without a base file, every suffix is new.
+		before := base
+		if before == nil {
+			before = &parser.File{}
+		}
+		errs := parser.BlockedSuffixes(before, psl, blocked)
+		psl.Errors = append(psl.Errors, errs...)
+		validated.Checks = append(validated.Checks, parser.CheckResult{
+			Name:     "blocklist",
+			Category: "changes",
+			Errors:   errs,
+		})
+	}
+
+	return psl, validated
+}
+
+// readBlocklist returns the domains listed in the blocklist file at
+// path, one per line. Blank lines and lines starting with # are
+// ignored. The result is not nil, even if the file lists no domains.
+func readBlocklist(path string) ([]string, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ret := []string{}
+	for _, line := range strings.Split(string(bs), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ret = append(ret, line)
+	}
+	return ret, nil
+}
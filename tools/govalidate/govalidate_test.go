@@ -0,0 +1,98 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	diff "github.com/google/go-cmp/cmp"
+	"github.com/publicsuffix/list/tools/internal/parser"
+)
+
+func TestValidateSummary(t *testing.T) {
+	t.Parallel()
+
+	lines := func(lines ...string) []byte {
+		return []byte(strings.Join(lines, "\n") + "\n")
+	}
+	base := parser.Parse(lines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// com : https://en.wikipedia.org/wiki/.com",
+		"com",
+		"",
+		"// ===END ICANN DOMAINS===",
+		"",
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc : https://duck.com",
+		"// Submitted by Not A Duck <duck@duck.com>",
+		"duck.com",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	))
+	// The change edits the ICANN section, which is a warning, and
+	// adds a blocked suffix, but passes all the checks of a single
+	// file.
+	in := lines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// com : https://en.wikipedia.org/wiki/.com",
+		"com",
+		"co.com",
+		"",
+		"// ===END ICANN DOMAINS===",
+		"",
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc : https://duck.com",
+		"// Submitted by Not A Duck <duck@duck.com>",
+		"duck.com",
+		"",
+		"// Goose Gang : https://goose.com",
+		"// Submitted by Not A Goose <goose@goose.com>",
+		"goose.com",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	)
+
+	psl, summary := validate(in, "psl.dat", base, []string{"goose.com"}, parser.Options{Reference: base})
+
+	type rollup struct {
+		Category         string
+		Checks, Passed   int
+		Errors, Warnings []string
+	}
+	var got []rollup
+	var summaryErrs int
+	for _, cat := range summary.Categories() {
+		summaryErrs += len(cat.Errors)
+		if len(cat.Errors) == 0 && len(cat.Warnings) == 0 {
+			continue
+		}
+		r := rollup{Category: cat.Category, Checks: cat.Checks, Passed: cat.Passed}
+		for _, info := range cat.Errors {
+			r.Errors = append(r.Errors, info.Type)
+		}
+		for _, info := range cat.Warnings {
+			r.Warnings = append(r.Warnings, info.Type)
+		}
+		got = append(got, r)
+	}
+	want := []rollup{
+		{"changes", 2, 1, []string{"blocked_suffix"}, []string{"icann_section_modified"}},
+	}
+	if d := diff.Diff(got, want); d != "" {
+		t.Errorf("summary categories with findings are wrong (-got+want):\n%s", d)
+	}
+	if summaryErrs != len(psl.Errors) {
+		t.Errorf("summary has %d errors, file has %d", summaryErrs, len(psl.Errors))
+	}
+
+	// Without a base or blocklist, there are no change checks.
+	_, summary = validate(in, "psl.dat", nil, nil, parser.Options{})
+	for _, cat := range summary.Categories() {
+		if cat.Category == "changes" {
+			t.Errorf("summary has changes category without a base or blocklist: %+v", cat)
+		}
+	}
+}
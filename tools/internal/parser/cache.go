@@ -0,0 +1,204 @@
+package parser
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"reflect"
+	"sync"
+)
+
+// ValidationCache memoizes the results of ValidateSuffixes, so that
+// repeatedly validating revisions of the same file only validates the
+// blocks that changed. It is safe for concurrent use. The zero value
+// is an empty cache ready to use.
+//
+// Results are keyed on a hash of the block's section and source text,
+// not its position in the file, so a block that only moved, for
+// example because lines were added above it, is not validated again.
+// Cached errors are moved to the block's current position before they
+// are returned.
+//
+// The cache is never pruned, so it grows with every distinct block it
+// has validated. Long-running users that validate many unrelated
+// files should replace the cache from time to time, for example
+// whenever the base version of the PSL changes.
+type ValidationCache struct {
+	// Options configures the validations. Cached results don't
+	// record the options they were computed with, so Options must
+	// not change once the cache is in use.
+	Options Options
+
+	mu      sync.Mutex
+	results map[[sha256.Size]byte]cachedValidation
+}
+
+// cachedValidation is the result of validating a block of suffixes,
+// located as if the block were at the start of a file with no name,
+// that is at the zero blockPosition.
+type cachedValidation struct {
+	errs, warnings []error
+}
+
+// ValidateSuffixes is like the package-level ValidateSuffixes, but
+// returns the cached result if a block with the same text was
+// validated before in the same section.
+func (c *ValidationCache) ValidateSuffixes(block Suffixes, section string) (errs, warnings []error) {
+	key := validationKey(block, section)
+	pos := positionOf(block)
+
+	c.mu.Lock()
+	res, ok := c.results[key]
+	c.mu.Unlock()
+	if !ok {
+		// Validate outside the lock, so that concurrent callers don't
+		// wait for each other. At worst a block is validated twice.
+		rel := relocate(block, pos, blockPosition{})
+		res.errs, res.warnings = ValidateSuffixes(rel, section, c.Options)
+		c.mu.Lock()
+		if c.results == nil {
+			c.results = map[[sha256.Size]byte]cachedValidation{}
+		}
+		c.results[key] = res
+		c.mu.Unlock()
+	}
+
+	return relocateErrors(res.errs, blockPosition{}, pos), relocateErrors(res.warnings, blockPosition{}, pos)
+}
+
+// ValidateFile runs ValidateSuffixes on every block of suffixes in the
+// ICANN and private domains sections of f, and returns all the errors
+// and warnings found, in file order.
+//
+// Like ValidateSuffixes, it only runs the validations that need a
+// single block. Use Parse to run all validations.
+func (c *ValidationCache) ValidateFile(f *File) (errs, warnings []error) {
+	for _, section := range []string{"ICANN DOMAINS", "PRIVATE DOMAINS"} {
+		for _, block := range f.SuffixBlocksInSection(section) {
+			e, w := c.ValidateSuffixes(block, section)
+			errs = append(errs, e...)
+			warnings = append(warnings, w...)
+		}
+	}
+	return errs, warnings
+}
+
+// validationKey returns the cache key for validating block in the
+// named section.
+func validationKey(block Suffixes, section string) [sha256.Size]byte {
+	h := sha256.New()
+	var buf [8]byte
+	writeString := func(s string) {
+		binary.LittleEndian.PutUint64(buf[:], uint64(len(s)))
+		h.Write(buf[:])
+		h.Write([]byte(s))
+	}
+	writeInt := func(n int) {
+		binary.LittleEndian.PutUint64(buf[:], uint64(n))
+		h.Write(buf[:])
+	}
+
+	writeString(section)
+	// Whether byte offsets are known affects the cached errors, but
+	// the offsets themselves don't.
+	if block.offsets != nil {
+		writeInt(1)
+	} else {
+		writeInt(0)
+	}
+	writeInt(len(block.lines))
+	for _, line := range block.lines {
+		writeString(line)
+	}
+
+	var ret [sha256.Size]byte
+	h.Sum(ret[:0])
+	return ret
+}
+
+// blockPosition is the position of a block of suffixes in its file.
+type blockPosition struct {
+	path       string
+	lineOffset int
+	byteOffset int // the offset of the first byte, or 0 if unknown
+}
+
+// positionOf returns the position of block in its file.
+func positionOf(block Suffixes) blockPosition {
+	ret := blockPosition{
+		path:       block.path,
+		lineOffset: block.lineOffset,
+	}
+	if start, _, ok := block.byteRange(); ok {
+		ret.byteOffset = start
+	}
+	return ret
+}
+
+// relocateErrors returns errs, whose locations are relative to from,
+// moved to the same place relative to to. errs is not modified.
+func relocateErrors(errs []error, from, to blockPosition) []error {
+	if errs == nil {
+		return nil
+	}
+	ret := make([]error, 0, len(errs))
+	for _, err := range errs {
+		ret = append(ret, relocate(err, from, to))
+	}
+	return ret
+}
+
+var sourceType = reflect.TypeOf(Source{})
+
+// relocate returns a copy of v, with every Source it contains moved
+// from being relative to from to being relative to to. Sources are
+// found in exported fields of structs, including embedded ones, and
+// in slices, which are copied rather than modified.
+func relocate[T any](v T, from, to blockPosition) T {
+	rv := reflect.New(reflect.TypeOf(v)).Elem()
+	rv.Set(reflect.ValueOf(v))
+	relocateValue(rv, from, to)
+	return rv.Interface().(T)
+}
+
+func relocateValue(v reflect.Value, from, to blockPosition) {
+	if v.Type() == sourceType {
+		v.Set(reflect.ValueOf(relocateSource(v.Interface().(Source), from, to)))
+		return
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Field(i); f.CanSet() {
+				relocateValue(f, from, to)
+			}
+		}
+	case reflect.Slice:
+		if v.IsNil() || v.Type().Elem().Kind() != reflect.Struct {
+			return
+		}
+		cp := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(cp, v)
+		for i := 0; i < cp.Len(); i++ {
+			relocateValue(cp.Index(i), from, to)
+		}
+		v.Set(cp)
+	}
+}
+
+// relocateSource returns src, which is relative to from, moved to the
+// same place relative to to.
+func relocateSource(src Source, from, to blockPosition) Source {
+	src.path = to.path
+	src.lineOffset += to.lineOffset - from.lineOffset
+	if src.offsets != nil {
+		offsets := make([][2]int, len(src.offsets))
+		for i, span := range src.offsets {
+			offsets[i] = [2]int{
+				span[0] + to.byteOffset - from.byteOffset,
+				span[1] + to.byteOffset - from.byteOffset,
+			}
+		}
+		src.offsets = offsets
+	}
+	return src
+}
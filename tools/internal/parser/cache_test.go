@@ -0,0 +1,121 @@
+package parser
+
+import (
+	"bytes"
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestValidationCache(t *testing.T) {
+	t.Parallel()
+
+	in := byteLines(
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc: https://example.com",
+		"// Submitted by Not A Duck <duck@example.com>",
+		"example.com",
+		"",
+		"// Goose Gang: http://example.org",
+		"// Submitted by Very Much A Goose <goose@example.org>",
+		"example.org",
+		"a.example.org",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+		"",
+	)
+	f := Parse(in)
+
+	var c ValidationCache
+	wantErrs, wantWarnings := (&ValidationCache{}).ValidateFile(f)
+	if len(wantWarnings) == 0 {
+		t.Fatal("test file has no single-block warnings")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs, warnings := c.ValidateFile(f)
+			checkDiff(t, "cached errors", errs, wantErrs)
+			checkDiff(t, "cached warnings", warnings, wantWarnings)
+		}()
+	}
+	wg.Wait()
+	if len(c.results) != 2 {
+		t.Errorf("cache has %d results, want 2", len(c.results))
+	}
+
+	// Moving a block changes the location of its errors, but not
+	// whether it has any, so the cached result is moved along with
+	// it.
+	moved := ParseWithOptions(append([]byte("// A new top-level comment\n\n"), in...), "psl.dat", Options{})
+	_, warnings := c.ValidateFile(moved)
+	_, want := ValidateSuffixes(moved.AllSuffixBlocks()[1], "PRIVATE DOMAINS", Options{})
+	checkDiff(t, "warnings after moving blocks", warnings, want)
+	checkDiff(t, "described warnings after moving blocks", Describe("", warnings), Describe("", want))
+	if len(c.results) != 2 {
+		t.Errorf("cache has %d results, want 2", len(c.results))
+	}
+
+	// Editing a block validates it again.
+	edited := Parse(bytes.Replace(in, []byte("a.example.org"), []byte("b.example.org"), 1))
+	c.ValidateFile(edited)
+	if len(c.results) != 3 {
+		t.Errorf("cache has %d results, want 3", len(c.results))
+	}
+}
+
+// TestValidationCacheRealList checks that cached results for a
+// revision of the real list that moves every block are the same as
+// validating the revision from scratch.
+func TestValidationCacheRealList(t *testing.T) {
+	bs, err := os.ReadFile("../../../public_suffix_list.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var c ValidationCache
+	c.ValidateFile(ParseWithOptions(bs, "psl.dat", Options{}))
+
+	revised := ParseWithOptions(append([]byte("// A new top-level comment\n\n"), bs...), "psl.dat", Options{})
+	gotErrs, gotWarnings := c.ValidateFile(revised)
+	wantErrs, wantWarnings := (&ValidationCache{}).ValidateFile(revised)
+	checkDiff(t, "cached errors", Describe("", gotErrs), Describe("", wantErrs))
+	checkDiff(t, "cached warnings", Describe("", gotWarnings), Describe("", wantWarnings))
+}
+
+func BenchmarkValidationCache(b *testing.B) {
+	bs, err := os.ReadFile("../../../public_suffix_list.dat")
+	if err != nil {
+		b.Fatal(err)
+	}
+	f := Parse(bs)
+
+	b.Run("uncached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			(&ValidationCache{}).ValidateFile(f)
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		var c ValidationCache
+		c.ValidateFile(f)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			c.ValidateFile(f)
+		}
+	})
+	// A revision that adds lines near the top of the file, which
+	// moves almost every block.
+	revised := Parse(append([]byte("// A new top-level comment\n\n"), bs...))
+	b.Run("cached_revision", func(b *testing.B) {
+		var c ValidationCache
+		c.ValidateFile(f)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			c.ValidateFile(revised)
+		}
+	})
+}
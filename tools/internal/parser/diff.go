@@ -0,0 +1,345 @@
+package parser
+
+import "strings"
+
+// Diff is the difference between the suffixes of two PSL files.
+type Diff struct {
+	// Added lists the suffixes that are only in the newer file, in
+	// the order they appear in that file.
+	Added []DiffEntry
+	// Removed lists the suffixes that are only in the older file, in
+	// the order they appear in that file.
+	Removed []DiffEntry
+	// Changed lists the suffixes that are in both files, but whose
+	// section or block metadata (entity name, URL or submitter)
+	// differs, in the order they appear in the newer file.
+	Changed []DiffChange
+}
+
+// DiffEntry is a suffix in a Diff.
+type DiffEntry struct {
+	// Section is the name of the file section that the suffix is in,
+	// or the empty string if it is not in any section.
+	Section string
+	// Suffixes is the block that contains Suffix.
+	Suffixes Suffixes
+	// Suffix is the suffix entry itself.
+	Suffix Source
+}
+
+// DiffChange is a suffix whose metadata differs between two files.
+type DiffChange struct {
+	Old DiffEntry
+	New DiffEntry
+}
+
+// DiffSuffixes returns the difference between the suffixes of before
+// and after.
+//
+// Suffixes are compared by their text, so wildcard ("*.example.com")
+// and exception ("!www.example.com") rules are distinct from the
+// plain suffix "example.com". Moving a suffix to a different place in
+// the file is not a change, unless it also moves to a different
+// section or block metadata.
+func DiffSuffixes(before, after *File) Diff {
+	var ret Diff
+
+	old := map[string]DiffEntry{}
+	for _, e := range before.allEntries() {
+		old[e.Suffix.Text()] = e
+	}
+	cur := map[string]bool{}
+
+	for _, e := range after.allEntries() {
+		key := e.Suffix.Text()
+		cur[key] = true
+		prev, ok := old[key]
+		if !ok {
+			ret.Added = append(ret.Added, e)
+		} else if !sameMetadata(prev, e) {
+			ret.Changed = append(ret.Changed, DiffChange{
+				Old: prev,
+				New: e,
+			})
+		}
+	}
+
+	for _, e := range before.allEntries() {
+		if !cur[e.Suffix.Text()] {
+			ret.Removed = append(ret.Removed, e)
+		}
+	}
+
+	return ret
+}
+
+// allEntries returns all suffix entries in f, in file order.
+func (f *File) allEntries() []DiffEntry {
+	var ret []DiffEntry
+	var curSection string
+	for _, block := range f.Blocks {
+		switch v := block.(type) {
+		case StartSection:
+			curSection = v.Name
+		case EndSection:
+			curSection = ""
+		case Suffixes:
+			for _, entry := range v.Entries {
+				ret = append(ret, DiffEntry{
+					Section:  curSection,
+					Suffixes: v,
+					Suffix:   entry,
+				})
+			}
+		}
+	}
+	return ret
+}
+
+// sameMetadata reports whether a and b are in the same section and
+// have the same block metadata.
+func sameMetadata(a, b DiffEntry) bool {
+	if a.Section != b.Section || a.Suffixes.Entity != b.Suffixes.Entity {
+		return false
+	}
+
+	aURL, bURL := "", ""
+	if a.Suffixes.URL != nil {
+		aURL = a.Suffixes.URL.String()
+	}
+	if b.Suffixes.URL != nil {
+		bURL = b.Suffixes.URL.String()
+	}
+	if aURL != bURL {
+		return false
+	}
+
+	aSubmitter, bSubmitter := "", ""
+	if a.Suffixes.Submitter != nil {
+		aSubmitter = a.Suffixes.Submitter.String()
+	}
+	if b.Suffixes.Submitter != nil {
+		bSubmitter = b.Suffixes.Submitter.String()
+	}
+	return aSubmitter == bSubmitter
+}
+
+// ValidateChanges runs validations that apply to the change from
+// before to after, rather than to a single file. It returns the
+// errors and warnings found, split like File.Errors and
+// File.Warnings. opts configures the validations, like for
+// ParseWithOptions.
+func ValidateChanges(before, after *File, opts Options) (errs, warnings []error) {
+	var ret []error
+
+	diff := DiffSuffixes(before, after)
+	for _, e := range diff.Added {
+		if e.Section == "ICANN DOMAINS" {
+			ret = append(ret, ICANNSectionModified{
+				Suffixes: e.Suffixes,
+				Suffix:   e.Suffix,
+			})
+		}
+	}
+	for _, e := range diff.Added {
+		if err := parentAlreadySuffix(before, e); err != nil {
+			ret = append(ret, err)
+		}
+	}
+	for _, c := range diff.Changed {
+		if c.Old.Section == "ICANN DOMAINS" || c.New.Section == "ICANN DOMAINS" {
+			ret = append(ret, ICANNSectionModified{
+				Suffixes: c.New.Suffixes,
+				Suffix:   c.New.Suffix,
+			})
+		}
+	}
+	for _, e := range diff.Removed {
+		if e.Section == "ICANN DOMAINS" {
+			ret = append(ret, ICANNSuffixRemoved{
+				Suffix: e.Suffix,
+			})
+		}
+	}
+
+	ret = append(ret, danglingExceptions(after, diff.Removed)...)
+
+	for _, err := range ret {
+		if _, ok := err.(DanglingException); ok && opts.DanglingExceptionErrors {
+			errs = append(errs, err)
+		} else if errorSeverity(err) == SeverityWarning {
+			warnings = append(warnings, err)
+		} else {
+			errs = append(errs, err)
+		}
+	}
+	return errs, warnings
+}
+
+// danglingExceptions returns a DanglingException error for every
+// plain suffix in removed that after still has an exception for.
+func danglingExceptions(after *File, removed []DiffEntry) []error {
+	type exception struct {
+		block Suffixes
+		entry Source
+	}
+	exceptions := map[string]exception{}
+	for _, block := range after.AllSuffixBlocks() {
+		for _, entry := range block.Entries {
+			if domain, ok := strings.CutPrefix(entry.Text(), "!"); ok {
+				exceptions[domain] = exception{block, entry}
+			}
+		}
+	}
+
+	var ret []error
+	for _, e := range removed {
+		if !isPlainSuffix(e.Suffix.Text()) {
+			continue
+		}
+		if exc, ok := exceptions[e.Suffix.Text()]; ok {
+			ret = append(ret, DanglingException{
+				Suffixes:  exc.block,
+				Exception: exc.entry,
+				Removed:   e.Suffix,
+			})
+		}
+	}
+	return ret
+}
+
+// parentAlreadySuffix returns a SuffixParentAlreadySuffix error if the
+// added entry e was already a public suffix according to the rules of
+// before, because of a wildcard on its parent domain. To stay
+// conservative, only plain suffixes are checked, and wildcards in e's
+// own block are left to requireNoWildcardCoveredSuffixes.
+func parentAlreadySuffix(before *File, e DiffEntry) error {
+	if !isPlainSuffix(e.Suffix.Text()) {
+		return nil
+	}
+	name, err := lookupName(e.Suffix.Text())
+	if err != nil {
+		return nil
+	}
+	suffix, r, ok := matchRule(before, name)
+	if !ok || suffix != name || r.entry.Text() != "*."+parentDomain(name) {
+		return nil
+	}
+	for _, entry := range e.Suffixes.Entries {
+		if entry.Text() == r.entry.Text() {
+			return nil
+		}
+	}
+	return SuffixParentAlreadySuffix{
+		Suffixes: e.Suffixes,
+		Suffix:   e.Suffix,
+		Rule:     r.entry,
+		Entity:   r.block.Entity,
+	}
+}
+
+// BlockedSuffixes returns a BlockedSuffix error for every suffix that
+// is added or changed from before to after, and that is one of the
+// domains in blocklist or under one of them. Wildcard and exception
+// rules are matched by their base domain, and domains are compared
+// case-insensitively.
+//
+// Use an empty File as before to check every suffix in after.
+func BlockedSuffixes(before, after *File, blocklist []string) []error {
+	var ret []error
+
+	check := func(e DiffEntry) {
+		domain := entryDomain(e.Suffix.Text())
+		for _, blocked := range blocklist {
+			blocked = strings.ToLower(blocked)
+			if domain == blocked || strings.HasSuffix(domain, "."+blocked) {
+				ret = append(ret, BlockedSuffix{
+					Suffixes: e.Suffixes,
+					Suffix:   e.Suffix,
+					Blocked:  blocked,
+				})
+				return
+			}
+		}
+	}
+
+	diff := DiffSuffixes(before, after)
+	for _, e := range diff.Added {
+		check(e)
+	}
+	for _, c := range diff.Changed {
+		check(c.New)
+	}
+	return ret
+}
+
+// ChangedBlocks returns the blocks of suffixes in after whose source
+// text does not appear as a block of suffixes in before. Any edit to a
+// block, including to its header or inline comments, makes it a
+// changed block.
+func ChangedBlocks(before, after *File) []Suffixes {
+	old := map[string]bool{}
+	for _, block := range before.AllSuffixBlocks() {
+		old[block.Text()] = true
+	}
+
+	var ret []Suffixes
+	for _, block := range after.AllSuffixBlocks() {
+		if !old[block.Text()] {
+			ret = append(ret, block)
+		}
+	}
+	return ret
+}
+
+// OnlyChanged returns the errors in errs, which were produced by
+// parsing after, that are relevant to the changes from before to
+// after. This is useful to validate a proposed change without
+// reporting legacy problems in unrelated parts of the file.
+//
+// An error is relevant if it is located in a block that changed (see
+// ChangedBlocks), if it refers to a suffix or block elsewhere in the
+// file that changed, such as the other listing of a duplicate suffix,
+// or if it is not located within any block of suffixes at all, since
+// such errors concern the structure of the whole file.
+func OnlyChanged(errs []error, before, after *File) []error {
+	changed := ChangedBlocks(before, after)
+	all := after.AllSuffixBlocks()
+
+	var ret []error
+	for _, err := range errs {
+		e, ok := err.(interface{ location() Source })
+		if !ok || overlapsAny(e.location(), changed) || !overlapsAny(e.location(), all) || relatedChanged(err, changed) {
+			ret = append(ret, err)
+		}
+	}
+	return ret
+}
+
+// relatedChanged reports whether err refers to other suffixes or
+// blocks in addition to its location, and any of them overlaps one of
+// changed.
+func relatedChanged(err error, changed []Suffixes) bool {
+	e, ok := err.(interface{ related() []Source })
+	if !ok {
+		return false
+	}
+	for _, src := range e.related() {
+		if overlapsAny(src, changed) {
+			return true
+		}
+	}
+	return false
+}
+
+// overlapsAny reports whether src shares any lines with one of blocks.
+func overlapsAny(src Source, blocks []Suffixes) bool {
+	start, end := src.lineRange()
+	for _, block := range blocks {
+		blockStart, blockEnd := block.lineRange()
+		if start <= blockEnd && blockStart <= end {
+			return true
+		}
+	}
+	return false
+}
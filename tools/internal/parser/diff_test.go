@@ -0,0 +1,442 @@
+package parser
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDiffSuffixes(t *testing.T) {
+	t.Parallel()
+
+	before := Parse(byteLines(
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc: https://example.com",
+		"// Submitted by Not A Duck <duck@example.com>",
+		"example.com",
+		"*.example.com",
+		"",
+		"// Goose Gang: https://example.org",
+		"// Submitted by Very Much A Goose <goose@example.org>",
+		"example.net",
+		"example.org",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	))
+	after := Parse(byteLines(
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc: https://example.com",
+		"// Submitted by Not A Duck <duck@example.com>",
+		"example.com",
+		"pond.example.com",
+		"",
+		"// Goose Gang: https://geese.example.org",
+		"// Submitted by Very Much A Goose <goose@example.org>",
+		"example.net",
+		"example.org",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	))
+
+	got := DiffSuffixes(before, after)
+
+	type entry struct {
+		Section, Entity, Suffix string
+		Line                    int
+	}
+	simplify := func(es []DiffEntry) []entry {
+		var ret []entry
+		for _, e := range es {
+			ret = append(ret, entry{e.Section, e.Suffixes.Entity, e.Suffix.Text(), e.Suffix.lineOffset})
+		}
+		return ret
+	}
+
+	wantAdded := []entry{
+		{"PRIVATE DOMAINS", "DuckCorp Inc", "pond.example.com", 5},
+	}
+	wantRemoved := []entry{
+		{"PRIVATE DOMAINS", "DuckCorp Inc", "*.example.com", 5},
+	}
+	checkDiff(t, "added suffixes", simplify(got.Added), wantAdded)
+	checkDiff(t, "removed suffixes", simplify(got.Removed), wantRemoved)
+
+	var gotChanged []string
+	for _, c := range got.Changed {
+		if c.Old.Suffix.Text() != c.New.Suffix.Text() {
+			t.Errorf("changed entry has mismatched suffixes %q and %q", c.Old.Suffix.Text(), c.New.Suffix.Text())
+		}
+		if c.Old.Suffixes.URL.String() != "https://example.org" || c.New.Suffixes.URL.String() != "https://geese.example.org" {
+			t.Errorf("changed entry %q has wrong URLs, got %s -> %s", c.New.Suffix.Text(), c.Old.Suffixes.URL, c.New.Suffixes.URL)
+		}
+		gotChanged = append(gotChanged, c.New.Suffix.Text())
+	}
+	checkDiff(t, "changed suffixes", gotChanged, []string{"example.net", "example.org"})
+}
+
+func TestDiffSuffixesIdentical(t *testing.T) {
+	t.Parallel()
+
+	psl := byteLines(
+		"// DuckCorp Inc: https://example.com",
+		"example.com",
+	)
+	got := DiffSuffixes(Parse(psl), Parse(psl))
+	checkDiff(t, "diff of identical files", got, Diff{})
+}
+
+func TestOnlyChanged(t *testing.T) {
+	t.Parallel()
+
+	before := Parse(byteLines(
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc: https://example.com",
+		"// Submitted by Not A Duck <duck@example.com>",
+		"example.com",
+		"",
+		"// Goose Gang: https://example.org",
+		"example.org",
+		"example.net",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+		"",
+	))
+	after := Parse(byteLines(
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc: https://example.com",
+		"// Submitted by Not A Duck <duck@example.com>",
+		"example.com",
+		"",
+		"// Goose Gang: https://example.org",
+		"example.org",
+		"example.net",
+		"",
+		"// Swan Sisters: https://swan.example.net",
+		"swan.example.net",
+		"",
+		"// A top-level comment that is long enough to be reported as an overly long line.",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+		"",
+	))
+
+	changed := ChangedBlocks(before, after)
+	if len(changed) != 1 || changed[0].Entity != "Swan Sisters" {
+		t.Errorf("wrong changed blocks: %v", changed)
+	}
+
+	describe := func(errs []error) []string {
+		var ret []string
+		for _, info := range Describe("", errs) {
+			ret = append(ret, fmt.Sprintf("%s %d", info.Type, info.StartLine))
+		}
+		return ret
+	}
+
+	// Goose Gang's missing email and unsorted suffixes predate the
+	// change, and are filtered out.
+	checkDiff(t, "all errors", describe(after.Errors), []string{
+		"missing_entity_email 7",
+		"missing_entity_email 11",
+	})
+	checkDiff(t, "errors about changes", describe(OnlyChanged(after.Errors, before, after)), []string{
+		"missing_entity_email 11",
+	})
+	checkDiff(t, "all warnings", describe(after.Warnings), []string{
+		"long_line 14",
+		"unsorted_suffixes 9",
+	})
+	checkDiff(t, "warnings about changes", describe(OnlyChanged(after.Warnings, before, after)), []string{
+		"long_line 14",
+	})
+}
+
+func TestOnlyChangedInsertedBefore(t *testing.T) {
+	t.Parallel()
+
+	before := Parse(byteLines(
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// Zeta Corp: https://zeta.com",
+		"// Submitted by Zeta Admin <admin@zeta.com>",
+		"zeta.com",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+		"",
+	))
+	// The new block comes first, so the errors for the duplicates are
+	// located at the existing, unchanged block.
+	after := Parse(byteLines(
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// Zeta Corp: https://zeta.com",
+		"// Submitted by Zeta Impostor <impostor@zeta.com>",
+		"zeta.com",
+		"",
+		"// Zeta Corp: https://zeta.com",
+		"// Submitted by Zeta Admin <admin@zeta.com>",
+		"zeta.com",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+		"",
+	))
+
+	var got []string
+	for _, info := range Describe("", OnlyChanged(after.Errors, before, after)) {
+		got = append(got, fmt.Sprintf("%s %d", info.Type, info.StartLine))
+	}
+	checkDiff(t, "errors about changes", got, []string{
+		"duplicate_entity_name 7",
+		"duplicate_suffix 9",
+	})
+}
+
+func TestValidateChanges(t *testing.T) {
+	t.Parallel()
+
+	before := Parse(byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// com : https://www.iana.org/domains/root/db/com.html",
+		"com",
+		"",
+		"// net : https://www.iana.org/domains/root/db/net.html",
+		"net",
+		"",
+		"// org : https://www.iana.org/domains/root/db/org.html",
+		"org",
+		"",
+		"// ===END ICANN DOMAINS===",
+		"",
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc: https://example.com",
+		"// Submitted by Not A Duck <duck@example.com>",
+		"example.com",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	))
+	after := Parse(byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// com : https://www.iana.org/domains/root/db/com.html",
+		"com",
+		"",
+		"// net : https://www.verisign.com",
+		"net",
+		"",
+		"// example : https://www.iana.org/domains/root/db/example.html",
+		"example",
+		"",
+		"// ===END ICANN DOMAINS===",
+		"",
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc: https://example.com",
+		"// Submitted by Not A Duck <duck@example.com>",
+		"example.com",
+		"pond.example.com",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	))
+
+	errs, got := ValidateChanges(before, after, Options{})
+	if len(errs) != 0 {
+		t.Errorf("ValidateChanges returned unexpected errors: %v", errs)
+	}
+	want := []error{
+		ICANNSectionModified{
+			Suffixes: after.AllSuffixBlocks()[2],
+			Suffix:   mkSrc(9, "example"),
+		},
+		ICANNSectionModified{
+			Suffixes: after.AllSuffixBlocks()[1],
+			Suffix:   mkSrc(6, "net"),
+		},
+		ICANNSuffixRemoved{
+			Suffix: mkSrc(9, "org"),
+		},
+	}
+	checkDiff(t, "ValidateChanges warnings", got, want)
+
+	if errs, warnings := ValidateChanges(before, before, Options{}); len(errs) != 0 || len(warnings) != 0 {
+		t.Errorf("ValidateChanges of identical files returned %v, %v", errs, warnings)
+	}
+}
+
+func TestSuffixParentAlreadySuffix(t *testing.T) {
+	t.Parallel()
+
+	before := Parse(byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// jp : https://en.wikipedia.org/wiki/.jp",
+		"jp",
+		"co.jp",
+		"*.kawasaki.jp",
+		"!city.kawasaki.jp",
+		"",
+		"// ===END ICANN DOMAINS===",
+		"",
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc: https://example.com",
+		"// Submitted by Not A Duck <duck@example.com>",
+		"*.lake.co.jp",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	))
+	after := Parse(byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// jp : https://en.wikipedia.org/wiki/.jp",
+		"jp",
+		"co.jp",
+		"*.kawasaki.jp",
+		"!city.kawasaki.jp",
+		"",
+		"// ===END ICANN DOMAINS===",
+		"",
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc: https://example.com",
+		"// Submitted by Not A Duck <duck@example.com>",
+		"*.lake.co.jp",
+		"a.lake.co.jp",
+		"",
+		"// Goose Inc: https://example.org",
+		"// Submitted by Not A Goose <goose@example.org>",
+		"city.kawasaki.jp",
+		"pond.kawasaki.jp",
+		"goose.co.jp",
+		"*.nest.goose.co.jp",
+		"a.nest.goose.co.jp",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	))
+
+	// Suffixes under exceptions, under plain suffixes, and under
+	// wildcards of their own block are fine.
+	errs, warnings := ValidateChanges(before, after, Options{})
+	if len(errs) != 0 {
+		t.Errorf("ValidateChanges returned unexpected errors: %v", errs)
+	}
+	var got []error
+	for _, err := range warnings {
+		if _, ok := err.(SuffixParentAlreadySuffix); ok {
+			got = append(got, err)
+		}
+	}
+	want := []error{
+		SuffixParentAlreadySuffix{
+			Suffixes: after.AllSuffixBlocks()[2],
+			Suffix:   mkSrc(20, "pond.kawasaki.jp"),
+			Rule:     mkSrc(5, "*.kawasaki.jp"),
+			Entity:   "jp",
+		},
+	}
+	checkDiff(t, "SuffixParentAlreadySuffix warnings", got, want)
+}
+
+func TestDanglingExceptions(t *testing.T) {
+	t.Parallel()
+
+	before := Parse(byteLines(
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc: https://example.com",
+		"// Submitted by Not A Duck <duck@example.com>",
+		"*.example.com",
+		"!foo.example.com",
+		"!bar.example.com",
+		"foo.example.com",
+		"bar.example.com",
+		"baz.example.com",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	))
+	after := Parse(byteLines(
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc: https://example.com",
+		"// Submitted by Not A Duck <duck@example.com>",
+		"*.example.com",
+		"!foo.example.com",
+		"bar.example.com",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	))
+
+	// Removing a suffix along with its exception, or one that never
+	// had an exception, is fine.
+	want := []error{
+		DanglingException{
+			Suffixes:  after.AllSuffixBlocks()[0],
+			Exception: mkSrc(5, "!foo.example.com"),
+			Removed:   mkSrc(7, "foo.example.com"),
+		},
+	}
+	errs, warnings := ValidateChanges(before, after, Options{})
+	checkDiff(t, "ValidateChanges errors", errs, []error(nil))
+	checkDiff(t, "ValidateChanges warnings", warnings, want)
+
+	errs, warnings = ValidateChanges(before, after, Options{DanglingExceptionErrors: true})
+	checkDiff(t, "ValidateChanges errors with DanglingExceptionErrors", errs, want)
+	checkDiff(t, "ValidateChanges warnings with DanglingExceptionErrors", warnings, []error(nil))
+}
+
+func TestBlockedSuffixes(t *testing.T) {
+	t.Parallel()
+
+	before := Parse(byteLines(
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc: https://example.com",
+		"// Submitted by Not A Duck <duck@example.com>",
+		"example.com",
+		"spam.example.com",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+		"",
+	))
+	after := Parse(byteLines(
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc: https://example.com",
+		"// Submitted by Not A Duck <duck@example.com>",
+		"example.com",
+		"spam.example.com",
+		"*.Phish.example.com",
+		"example.net",
+		"",
+		"// Goose Gang: https://example.org",
+		"// Submitted by Very Much A Goose <goose@example.org>",
+		"example.org",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+		"",
+	))
+	blocklist := []string{"spam.example.com", "phish.example.com", "EXAMPLE.org", "net"}
+
+	var got []string
+	for _, err := range BlockedSuffixes(before, after, blocklist) {
+		e := err.(BlockedSuffix)
+		got = append(got, e.Suffix.Text()+" "+e.Blocked)
+	}
+	want := []string{
+		// spam.example.com predates the change.
+		"*.Phish.example.com phish.example.com",
+		"example.net net",
+		"example.org example.org",
+	}
+	checkDiff(t, "blocked suffixes", got, want)
+
+	got = nil
+	for _, err := range BlockedSuffixes(&File{}, after, blocklist) {
+		got = append(got, err.(BlockedSuffix).Suffix.Text())
+	}
+	checkDiff(t, "blocked suffixes in the whole file", got, []string{"spam.example.com", "*.Phish.example.com", "example.net", "example.org"})
+}
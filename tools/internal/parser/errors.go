@@ -2,8 +2,38 @@ package parser
 
 import (
 	"fmt"
+	"strings"
+	"unicode"
 )
 
+// Severity is the severity of a parse or validation error.
+//
+// Every error type in this package has a Severity method, and a Code
+// method that returns a stable identifier for the kind of error, such
+// as "missing_entity_name". Codes don't change when error messages
+// are reworded, so tools can use them to route or suppress specific
+// kinds of errors.
+type Severity int
+
+const (
+	// SeverityError is for errors that make a PSL file invalid.
+	SeverityError Severity = iota
+	// SeverityWarning is for lint findings that should be reviewed,
+	// but that don't make a PSL file invalid on their own.
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+}
+
 // InvalidEncodingError reports that the input is encoded with
 // something other than UTF-8.
 type InvalidEncodingError struct {
@@ -14,6 +44,9 @@ func (e InvalidEncodingError) Error() string {
 	return fmt.Sprintf("file uses invalid character encoding %s", e.Encoding)
 }
 
+func (e InvalidEncodingError) Severity() Severity { return SeverityError }
+func (e InvalidEncodingError) Code() string       { return "invalid_encoding" }
+
 // UTF8BOMError reports that the input has an unnecessary UTF-8 byte
 // order mark (BOM) at the start.
 type UTF8BOMError struct{}
@@ -22,6 +55,9 @@ func (e UTF8BOMError) Error() string {
 	return "file starts with an unnecessary UTF-8 BOM (byte order mark)"
 }
 
+func (e UTF8BOMError) Severity() Severity { return SeverityError }
+func (e UTF8BOMError) Code() string       { return "utf8_bom" }
+
 // InvalidUTF8Error reports that a line contains bytes that are not
 // valid UTF-8.
 type InvalidUTF8Error struct {
@@ -32,6 +68,10 @@ func (e InvalidUTF8Error) Error() string {
 	return fmt.Sprintf("found non UTF-8 bytes at %s", e.Line.LocationString())
 }
 
+func (e InvalidUTF8Error) Severity() Severity { return SeverityError }
+func (e InvalidUTF8Error) Code() string       { return "invalid_utf8" }
+func (e InvalidUTF8Error) location() Source   { return e.Line }
+
 // DOSNewlineError reports that a line has a DOS style line ending.
 type DOSNewlineError struct {
 	Line Source
@@ -41,6 +81,10 @@ func (e DOSNewlineError) Error() string {
 	return fmt.Sprintf("%s has a DOS line ending (\\r\\n instead of just \\n)", e.Line.LocationString())
 }
 
+func (e DOSNewlineError) Severity() Severity { return SeverityError }
+func (e DOSNewlineError) Code() string       { return "dos_newline" }
+func (e DOSNewlineError) location() Source   { return e.Line }
+
 // TrailingWhitespaceError reports that a line has trailing whitespace.
 type TrailingWhitespaceError struct {
 	Line Source
@@ -50,6 +94,28 @@ func (e TrailingWhitespaceError) Error() string {
 	return fmt.Sprintf("%s has trailing whitespace", e.Line.LocationString())
 }
 
+func (e TrailingWhitespaceError) Severity() Severity { return SeverityError }
+func (e TrailingWhitespaceError) Code() string       { return "trailing_whitespace" }
+func (e TrailingWhitespaceError) location() Source   { return e.Line }
+
+// TrailingNewlineError reports that the file does not end with
+// exactly one newline.
+type TrailingNewlineError struct {
+	Line     Source // the last non-empty line of the file
+	Newlines int    // the number of newlines after Line
+}
+
+func (e TrailingNewlineError) Error() string {
+	if e.Newlines == 0 {
+		return fmt.Sprintf("%s: file does not end with a newline", e.Line.LocationString())
+	}
+	return fmt.Sprintf("%s: file ends with %d newlines, want 1", e.Line.LocationString(), e.Newlines)
+}
+
+func (e TrailingNewlineError) Severity() Severity { return SeverityWarning }
+func (e TrailingNewlineError) Code() string       { return "trailing_newline" }
+func (e TrailingNewlineError) location() Source   { return e.Line }
+
 // LeadingWhitespaceError reports that a line has leading whitespace.
 type LeadingWhitespaceError struct {
 	Line Source
@@ -59,6 +125,38 @@ func (e LeadingWhitespaceError) Error() string {
 	return fmt.Sprintf("%s has leading whitespace", e.Line.LocationString())
 }
 
+func (e LeadingWhitespaceError) Severity() Severity { return SeverityError }
+func (e LeadingWhitespaceError) Code() string       { return "leading_whitespace" }
+func (e LeadingWhitespaceError) location() Source   { return e.Line }
+
+// TabCharacterError reports that a line contains a tab character.
+type TabCharacterError struct {
+	Line Source
+}
+
+func (e TabCharacterError) Error() string {
+	return fmt.Sprintf("%s contains a tab character, use spaces instead", e.Line.LocationString())
+}
+
+func (e TabCharacterError) Severity() Severity { return SeverityWarning }
+func (e TabCharacterError) Code() string       { return "tab_character" }
+func (e TabCharacterError) location() Source   { return e.Line }
+
+// LongLineError reports that a line is longer than the maximum line
+// length (see Options.MaxLineLength).
+type LongLineError struct {
+	Line Source
+	Max  int
+}
+
+func (e LongLineError) Error() string {
+	return fmt.Sprintf("%s is longer than %d characters", e.Line.LocationString(), e.Max)
+}
+
+func (e LongLineError) Severity() Severity { return SeverityWarning }
+func (e LongLineError) Code() string       { return "long_line" }
+func (e LongLineError) location() Source   { return e.Line }
+
 // SectionInSuffixBlock reports that a comment within a block of
 // suffixes contains a section delimiter.
 type SectionInSuffixBlock struct {
@@ -69,6 +167,10 @@ func (e SectionInSuffixBlock) Error() string {
 	return fmt.Sprintf("section delimiters are not allowed in suffix block comment at %s", e.Line.LocationString())
 }
 
+func (e SectionInSuffixBlock) Severity() Severity { return SeverityError }
+func (e SectionInSuffixBlock) Code() string       { return "section_in_suffix_block" }
+func (e SectionInSuffixBlock) location() Source   { return e.Line }
+
 // UnclosedSectionError reports that a file section was not closed
 // properly before EOF.
 type UnclosedSectionError struct {
@@ -79,6 +181,10 @@ func (e UnclosedSectionError) Error() string {
 	return fmt.Sprintf("section %q started at %s, but is never closed", e.Start.Name, e.Start.LocationString())
 }
 
+func (e UnclosedSectionError) Severity() Severity { return SeverityError }
+func (e UnclosedSectionError) Code() string       { return "unclosed_section" }
+func (e UnclosedSectionError) location() Source   { return e.Start.Source }
+
 // NestedSectionError reports that a file section is being started
 // while already within a section, which the PSL format does not
 // allow.
@@ -91,6 +197,10 @@ func (e NestedSectionError) Error() string {
 	return fmt.Sprintf("new section %q started at %s while still in section %q (started at %s)", e.Inner.Name, e.Inner.LocationString(), e.Outer.Name, e.Outer.LocationString())
 }
 
+func (e NestedSectionError) Severity() Severity { return SeverityError }
+func (e NestedSectionError) Code() string       { return "nested_section" }
+func (e NestedSectionError) location() Source   { return e.Inner.Source }
+
 // UnstartedSectionError reports that a file section end marker was
 // found without a corresponding start.
 type UnstartedSectionError struct {
@@ -101,6 +211,10 @@ func (e UnstartedSectionError) Error() string {
 	return fmt.Sprintf("section %q closed at %s but was not started", e.End.Name, e.End.LocationString())
 }
 
+func (e UnstartedSectionError) Severity() Severity { return SeverityError }
+func (e UnstartedSectionError) Code() string       { return "unstarted_section" }
+func (e UnstartedSectionError) location() Source   { return e.End.Source }
+
 // MismatchedSectionError reports that a file section was started
 // under one name but ended under another.
 type MismatchedSectionError struct {
@@ -112,6 +226,10 @@ func (e MismatchedSectionError) Error() string {
 	return fmt.Sprintf("section %q closed at %s while in section %q (started at %s)", e.End.Name, e.End.LocationString(), e.Start.Name, e.Start.LocationString())
 }
 
+func (e MismatchedSectionError) Severity() Severity { return SeverityError }
+func (e MismatchedSectionError) Code() string       { return "mismatched_section" }
+func (e MismatchedSectionError) location() Source   { return e.End.Source }
+
 // UnknownSectionMarker reports that a line looks like a file section
 // marker (e.g. "===BEGIN ICANN DOMAINS==="), but is not one of the
 // recognized kinds of marker.
@@ -123,6 +241,41 @@ func (e UnknownSectionMarker) Error() string {
 	return fmt.Sprintf("unknown kind of section marker %q at %s", e.Line.Text(), e.Line.LocationString())
 }
 
+func (e UnknownSectionMarker) Severity() Severity { return SeverityError }
+func (e UnknownSectionMarker) Code() string       { return "unknown_section_marker" }
+func (e UnknownSectionMarker) location() Source   { return e.Line }
+
+// UnknownSectionName reports that a section marker names a section
+// other than "ICANN DOMAINS" or "PRIVATE DOMAINS". This is usually a
+// typo in the marker.
+type UnknownSectionName struct {
+	Line Source
+	Name string
+}
+
+func (e UnknownSectionName) Error() string {
+	return fmt.Sprintf(`unknown section name %q at %s, must be "ICANN DOMAINS" or "PRIVATE DOMAINS"`, e.Name, e.Line.LocationString())
+}
+
+func (e UnknownSectionName) Severity() Severity { return SeverityError }
+func (e UnknownSectionName) Code() string       { return "unknown_section_name" }
+func (e UnknownSectionName) location() Source   { return e.Line }
+
+// DuplicateSection reports that a file section is started more than
+// once.
+type DuplicateSection struct {
+	Previous StartSection
+	Start    StartSection
+}
+
+func (e DuplicateSection) Error() string {
+	return fmt.Sprintf("section %q started at %s was already started at %s", e.Start.Name, e.Start.LocationString(), e.Previous.LocationString())
+}
+
+func (e DuplicateSection) Severity() Severity { return SeverityError }
+func (e DuplicateSection) Code() string       { return "duplicate_section" }
+func (e DuplicateSection) location() Source   { return e.Start.Source }
+
 // UnterminatedSectionMarker reports that a section marker is missing
 // the required trailing "===", e.g. "===BEGIN ICANN DOMAINS".
 type UnterminatedSectionMarker struct {
@@ -133,6 +286,10 @@ func (e UnterminatedSectionMarker) Error() string {
 	return fmt.Sprintf(`section marker %q at %s is missing trailing "==="`, e.Line.Text(), e.Line.LocationString())
 }
 
+func (e UnterminatedSectionMarker) Severity() Severity { return SeverityError }
+func (e UnterminatedSectionMarker) Code() string       { return "unterminated_section_marker" }
+func (e UnterminatedSectionMarker) location() Source   { return e.Line }
+
 // MissingEntityName reports that a block of suffixes does not have a
 // parseable owner name in its header comment.
 type MissingEntityName struct {
@@ -143,6 +300,31 @@ func (e MissingEntityName) Error() string {
 	return fmt.Sprintf("could not find entity name for %s at %s", e.Suffixes.shortName(), e.Suffixes.LocationString())
 }
 
+func (e MissingEntityName) Severity() Severity { return SeverityError }
+func (e MissingEntityName) Code() string       { return "missing_entity_name" }
+func (e MissingEntityName) location() Source   { return e.Suffixes.Source }
+
+// InvalidEntityName reports that the entity name of a block of
+// suffixes contains a character that is invisible or not printable,
+// such as a control character or a zero-width space.
+type InvalidEntityName struct {
+	Suffixes Suffixes
+	Char     rune // the first disallowed character
+	Offset   int  // byte offset of Char in the entity name
+}
+
+func (e InvalidEntityName) Error() string {
+	fix := "remove it"
+	if unicode.IsSpace(e.Char) {
+		fix = "replace it with a plain space"
+	}
+	return fmt.Sprintf("entity name %q at %s contains disallowed character %U at offset %d, %s", e.Suffixes.Entity, e.Suffixes.LocationString(), e.Char, e.Offset, fix)
+}
+
+func (e InvalidEntityName) Severity() Severity { return SeverityError }
+func (e InvalidEntityName) Code() string       { return "invalid_entity_name" }
+func (e InvalidEntityName) location() Source   { return e.Suffixes.Source }
+
 // MissingEntityEmail reports that a block of suffixes does not have a
 // parseable contact email address in its header comment.
 type MissingEntityEmail struct {
@@ -152,3 +334,755 @@ type MissingEntityEmail struct {
 func (e MissingEntityEmail) Error() string {
 	return fmt.Sprintf("could not find a contact email for %s at %s", e.Suffixes.shortName(), e.Suffixes.LocationString())
 }
+
+func (e MissingEntityEmail) Severity() Severity { return SeverityError }
+func (e MissingEntityEmail) Code() string       { return "missing_entity_email" }
+func (e MissingEntityEmail) location() Source   { return e.Suffixes.Source }
+
+// MissingSubmissionReference reports that a block of suffixes in the
+// private domains section has neither a "Submitted by" line nor a link
+// to the pull request that added it in its header comment.
+type MissingSubmissionReference struct {
+	Suffixes Suffixes
+}
+
+func (e MissingSubmissionReference) Error() string {
+	return fmt.Sprintf("could not find a \"Submitted by\" line or pull request link for %s at %s", e.Suffixes.shortName(), e.Suffixes.LocationString())
+}
+
+func (e MissingSubmissionReference) Severity() Severity { return SeverityError }
+func (e MissingSubmissionReference) Code() string       { return "missing_submission_reference" }
+func (e MissingSubmissionReference) location() Source   { return e.Suffixes.Source }
+
+// MalformedEntityHeader reports that a header line of a block of
+// suffixes has an unbalanced or incorrectly nested bracket, which
+// makes the block's metadata parse wrong.
+type MalformedEntityHeader struct {
+	Suffixes Suffixes
+	Line     Source // the first malformed header line
+	Bracket  rune   // the unmatched bracket
+}
+
+func (e MalformedEntityHeader) Error() string {
+	return fmt.Sprintf("header of %s has an unmatched %q at %s", e.Suffixes.shortName(), e.Bracket, e.Line.LocationString())
+}
+
+func (e MalformedEntityHeader) Severity() Severity { return SeverityError }
+func (e MalformedEntityHeader) Code() string       { return "malformed_entity_header" }
+func (e MalformedEntityHeader) location() Source   { return e.Line }
+
+// InvalidEntityEmail reports that the contact email address of a
+// block of suffixes does not have a well-formed domain name.
+type InvalidEntityEmail struct {
+	Suffixes Suffixes
+}
+
+func (e InvalidEntityEmail) Error() string {
+	return fmt.Sprintf("contact email %q for %s at %s does not have a valid domain name", e.Suffixes.Submitter.Address, e.Suffixes.shortName(), e.Suffixes.LocationString())
+}
+
+func (e InvalidEntityEmail) Severity() Severity { return SeverityError }
+func (e InvalidEntityEmail) Code() string       { return "invalid_entity_email" }
+func (e InvalidEntityEmail) location() Source   { return e.Suffixes.Source }
+
+// PlaceholderMetadata reports that the entity name or contact of a
+// block of suffixes contains placeholder text, such as "Organization
+// Name", that was not replaced with real information.
+type PlaceholderMetadata struct {
+	Suffixes    Suffixes
+	Field       string // "entity name", "contact name" or "contact email"
+	Placeholder string // the placeholder text found, in lowercase
+}
+
+func (e PlaceholderMetadata) Error() string {
+	return fmt.Sprintf("%s for %s at %s contains placeholder text %q", e.Field, e.Suffixes.shortName(), e.Suffixes.LocationString(), e.Placeholder)
+}
+
+func (e PlaceholderMetadata) Severity() Severity { return SeverityError }
+func (e PlaceholderMetadata) Code() string       { return "placeholder_metadata" }
+func (e PlaceholderMetadata) location() Source   { return e.Suffixes.Source }
+
+// GenericEntityName reports that the entity name of a block in the
+// private section is too generic to identify who maintains it, for
+// example "Test" or "My Company".
+type GenericEntityName struct {
+	Suffixes Suffixes
+	Pattern  string // the Options.GenericEntityNames pattern that matched
+}
+
+func (e GenericEntityName) Error() string {
+	return fmt.Sprintf("entity name %q at %s is too generic to identify the maintainer, it matches %q", e.Suffixes.Entity, e.Suffixes.LocationString(), e.Pattern)
+}
+
+func (e GenericEntityName) Severity() Severity { return SeverityWarning }
+func (e GenericEntityName) Code() string       { return "generic_entity_name" }
+func (e GenericEntityName) location() Source   { return e.Suffixes.Source }
+
+// CommentDomainMismatch reports that the comments of a block in the
+// private section mention domains that are not related to any of the
+// block's suffixes, which suggests that the comments are out of date.
+type CommentDomainMismatch struct {
+	Suffixes Suffixes
+	Domains  []string // the mentioned domains, in lowercase
+}
+
+func (e CommentDomainMismatch) Error() string {
+	return fmt.Sprintf("comments of %s at %s mention domains that are not among its suffixes: %s", e.Suffixes.shortName(), e.Suffixes.LocationString(), strings.Join(e.Domains, ", "))
+}
+
+func (e CommentDomainMismatch) Severity() Severity { return SeverityWarning }
+func (e CommentDomainMismatch) Code() string       { return "comment_domain_mismatch" }
+func (e CommentDomainMismatch) location() Source   { return e.Suffixes.Source }
+
+// FreeEmailMaintainer reports that a block of several suffixes lists
+// a contact email address at a free email provider, which doesn't
+// show that the submitter speaks for the entity.
+type FreeEmailMaintainer struct {
+	Suffixes Suffixes
+}
+
+func (e FreeEmailMaintainer) Error() string {
+	return fmt.Sprintf("contact email %q for %s at %s is at a free email provider, check that the submitter represents the entity", e.Suffixes.Submitter.Address, e.Suffixes.shortName(), e.Suffixes.LocationString())
+}
+
+func (e FreeEmailMaintainer) Severity() Severity { return SeverityWarning }
+func (e FreeEmailMaintainer) Code() string       { return "free_email_maintainer" }
+func (e FreeEmailMaintainer) location() Source   { return e.Suffixes.Source }
+
+// InvalidEmailTLD reports that the contact email address of a block
+// of suffixes is not under any TLD listed in the ICANN domains
+// section, which usually means the address has a typo.
+type InvalidEmailTLD struct {
+	Suffixes Suffixes
+}
+
+func (e InvalidEmailTLD) Error() string {
+	return fmt.Sprintf("contact email %q for %s at %s is not under any ICANN TLD", e.Suffixes.Submitter.Address, e.Suffixes.shortName(), e.Suffixes.LocationString())
+}
+
+func (e InvalidEmailTLD) Severity() Severity { return SeverityWarning }
+func (e InvalidEmailTLD) Code() string       { return "invalid_email_tld" }
+func (e InvalidEmailTLD) location() Source   { return e.Suffixes.Source }
+
+// InvalidEntityURL reports that the header comment of a block of
+// suffixes contains a malformed URL, for example one with a
+// misspelled scheme or a missing host name.
+type InvalidEntityURL struct {
+	Suffixes Suffixes
+	URL      string // the malformed URL, as written in the header
+}
+
+func (e InvalidEntityURL) Error() string {
+	return fmt.Sprintf("invalid URL %q in header of %s at %s", e.URL, e.Suffixes.shortName(), e.Suffixes.LocationString())
+}
+
+func (e InvalidEntityURL) Severity() Severity { return SeverityError }
+func (e InvalidEntityURL) Code() string       { return "invalid_entity_url" }
+func (e InvalidEntityURL) location() Source   { return e.Suffixes.Source }
+
+// InsecureEntityURL reports that the URL of a block of suffixes uses
+// plain http instead of https.
+type InsecureEntityURL struct {
+	Suffixes Suffixes
+}
+
+func (e InsecureEntityURL) Error() string {
+	return fmt.Sprintf("URL %q for %s at %s should use https", e.Suffixes.URL, e.Suffixes.shortName(), e.Suffixes.LocationString())
+}
+
+func (e InsecureEntityURL) Severity() Severity { return SeverityWarning }
+func (e InsecureEntityURL) Code() string       { return "insecure_entity_url" }
+func (e InsecureEntityURL) location() Source   { return e.Suffixes.Source }
+
+// RedundantSuffix reports that a suffix is a subdomain of another
+// suffix in the same block of suffixes. This is usually redundant,
+// although there are legitimate reasons to list both.
+type RedundantSuffix struct {
+	Suffixes Suffixes
+	Suffix   Source // the subdomain
+	Parent   Source // the suffix that Suffix is a subdomain of
+}
+
+func (e RedundantSuffix) Error() string {
+	return fmt.Sprintf("suffix %q at %s is a subdomain of %q at %s, both listed by %s", e.Suffix.Text(), e.Suffix.LocationString(), e.Parent.Text(), e.Parent.LocationString(), e.Suffixes.shortName())
+}
+
+func (e RedundantSuffix) Severity() Severity { return SeverityWarning }
+func (e RedundantSuffix) Code() string       { return "redundant_suffix" }
+func (e RedundantSuffix) location() Source   { return e.Suffix }
+func (e RedundantSuffix) suffix() Source     { return e.Suffix }
+
+// NonCanonicalSuffix reports that a suffix is not written in its
+// canonical form. PSL suffixes must be lowercase, and
+// internationalized domain names must be written as Unicode
+// (U-labels) rather than Punycode (A-labels), like the rest of the
+// list. See requireCanonicalSuffixes.
+type NonCanonicalSuffix struct {
+	Suffixes Suffixes
+	Suffix   Source
+	Want     string // the canonical form of Suffix
+}
+
+func (e NonCanonicalSuffix) Error() string {
+	return fmt.Sprintf("suffix %q at %s is not in canonical form, want %q", e.Suffix.Text(), e.Suffix.LocationString(), e.Want)
+}
+
+func (e NonCanonicalSuffix) Severity() Severity { return SeverityError }
+func (e NonCanonicalSuffix) Code() string       { return "non_canonical_suffix" }
+func (e NonCanonicalSuffix) location() Source   { return e.Suffix }
+func (e NonCanonicalSuffix) suffix() Source     { return e.Suffix }
+
+// UnsortedSuffixes reports that the suffixes of a block are not
+// sorted in canonical order.
+type UnsortedSuffixes struct {
+	Suffixes Suffixes
+	Suffix   Source // the first suffix that is out of order
+	Before   Source // the suffix that Suffix should be moved in front of
+}
+
+func (e UnsortedSuffixes) Error() string {
+	return fmt.Sprintf("suffix %q at %s is not sorted correctly within %s, it should be before %q at %s", e.Suffix.Text(), e.Suffix.LocationString(), e.Suffixes.shortName(), e.Before.Text(), e.Before.LocationString())
+}
+
+func (e UnsortedSuffixes) Severity() Severity { return SeverityWarning }
+func (e UnsortedSuffixes) Code() string       { return "unsorted_suffixes" }
+func (e UnsortedSuffixes) location() Source   { return e.Suffix }
+func (e UnsortedSuffixes) suffix() Source     { return e.Suffix }
+
+// UnsortedExceptions reports that the exceptions to a wildcard
+// within a block are not sorted in canonical order.
+type UnsortedExceptions struct {
+	Suffixes  Suffixes
+	Wildcard  Source
+	Exception Source // the first exception that is out of order
+	Before    Source // the exception that Exception should be moved in front of
+}
+
+func (e UnsortedExceptions) Error() string {
+	return fmt.Sprintf("exceptions to wildcard %q at %s are not sorted correctly within %s, %q at %s should be before %q at %s", e.Wildcard.Text(), e.Wildcard.LocationString(), e.Suffixes.shortName(), e.Exception.Text(), e.Exception.LocationString(), e.Before.Text(), e.Before.LocationString())
+}
+
+func (e UnsortedExceptions) Severity() Severity { return SeverityWarning }
+func (e UnsortedExceptions) Code() string       { return "unsorted_exceptions" }
+func (e UnsortedExceptions) location() Source   { return e.Wildcard }
+func (e UnsortedExceptions) suffix() Source     { return e.Wildcard }
+
+// UnknownTLD reports that a suffix in the private domains section is
+// not under any TLD listed in the ICANN domains section.
+type UnknownTLD struct {
+	Suffixes Suffixes
+	Suffix   Source
+}
+
+func (e UnknownTLD) Error() string {
+	return fmt.Sprintf("suffix %q at %s is not under any ICANN TLD", e.Suffix.Text(), e.Suffix.LocationString())
+}
+
+func (e UnknownTLD) Severity() Severity { return SeverityError }
+func (e UnknownTLD) Code() string       { return "unknown_tld" }
+func (e UnknownTLD) location() Source   { return e.Suffix }
+func (e UnknownTLD) suffix() Source     { return e.Suffix }
+
+// DuplicateSuffix reports that a suffix is listed more than once,
+// possibly in a different case or with punycode instead of Unicode
+// labels.
+type DuplicateSuffix struct {
+	Suffixes Suffixes
+	Suffix   Source
+	Previous Source // the earlier listing of Suffix
+}
+
+func (e DuplicateSuffix) Error() string {
+	return fmt.Sprintf("suffix %q at %s duplicates %q at %s", e.Suffix.Text(), e.Suffix.LocationString(), e.Previous.Text(), e.Previous.LocationString())
+}
+
+func (e DuplicateSuffix) Severity() Severity { return SeverityError }
+func (e DuplicateSuffix) Code() string       { return "duplicate_suffix" }
+func (e DuplicateSuffix) location() Source   { return e.Suffix }
+func (e DuplicateSuffix) suffix() Source     { return e.Suffix }
+func (e DuplicateSuffix) related() []Source  { return []Source{e.Previous} }
+
+// ReservedSuffix reports that a suffix is a special-use domain name,
+// such as "localhost", or under one.
+type ReservedSuffix struct {
+	Suffixes Suffixes
+	Suffix   Source
+	Reserved string // the special-use name that Suffix is or is under
+}
+
+func (e ReservedSuffix) Error() string {
+	if strings.EqualFold(strings.TrimPrefix(strings.TrimPrefix(e.Suffix.Text(), "!"), "*."), e.Reserved) {
+		return fmt.Sprintf("suffix %q at %s is a reserved special-use name", e.Suffix.Text(), e.Suffix.LocationString())
+	}
+	return fmt.Sprintf("suffix %q at %s is under the reserved special-use name %q", e.Suffix.Text(), e.Suffix.LocationString(), e.Reserved)
+}
+
+func (e ReservedSuffix) Severity() Severity { return SeverityError }
+func (e ReservedSuffix) Code() string       { return "reserved_suffix" }
+func (e ReservedSuffix) location() Source   { return e.Suffix }
+func (e ReservedSuffix) suffix() Source     { return e.Suffix }
+
+// CrossSectionDuplicate reports that a suffix appears in both the
+// ICANN and private domains sections.
+type CrossSectionDuplicate struct {
+	ICANN   Source // the suffix in the ICANN domains section
+	Private Source // the suffix in the private domains section
+}
+
+func (e CrossSectionDuplicate) Error() string {
+	return fmt.Sprintf("suffix %q at %s is also in the ICANN section at %s", e.Private.Text(), e.Private.LocationString(), e.ICANN.LocationString())
+}
+
+func (e CrossSectionDuplicate) Severity() Severity { return SeverityError }
+func (e CrossSectionDuplicate) Code() string       { return "cross_section_duplicate" }
+func (e CrossSectionDuplicate) location() Source   { return e.Private }
+func (e CrossSectionDuplicate) suffix() Source     { return e.Private }
+func (e CrossSectionDuplicate) related() []Source  { return []Source{e.ICANN} }
+
+// DuplicateEntityName reports that two blocks of suffixes in the
+// private domains section have the same entity name. Names are
+// compared case-insensitively and ignoring differences in
+// whitespace.
+type DuplicateEntityName struct {
+	Suffixes Suffixes
+	Previous Suffixes // the earlier block with the same entity name
+}
+
+func (e DuplicateEntityName) Error() string {
+	return fmt.Sprintf("entity name %s at %s is already used by the block at %s", e.Suffixes.shortName(), e.Suffixes.LocationString(), e.Previous.LocationString())
+}
+
+func (e DuplicateEntityName) Severity() Severity { return SeverityError }
+func (e DuplicateEntityName) Code() string       { return "duplicate_entity_name" }
+func (e DuplicateEntityName) location() Source   { return e.Suffixes.Source }
+func (e DuplicateEntityName) related() []Source  { return []Source{e.Previous.Source} }
+
+// UnrelatedMaintainerEmail reports that the submitter email of a
+// block of suffixes is at a domain unrelated to any of the block's
+// suffixes or its URL. This is a common sign that the submitter does
+// not control the domains they are adding, although there are
+// legitimate reasons for it.
+type UnrelatedMaintainerEmail struct {
+	Suffixes Suffixes
+}
+
+func (e UnrelatedMaintainerEmail) Error() string {
+	return fmt.Sprintf("submitter email %q for %s at %s is not at a domain related to its suffixes", e.Suffixes.Submitter.Address, e.Suffixes.shortName(), e.Suffixes.LocationString())
+}
+
+func (e UnrelatedMaintainerEmail) Severity() Severity { return SeverityWarning }
+func (e UnrelatedMaintainerEmail) Code() string       { return "unrelated_maintainer_email" }
+func (e UnrelatedMaintainerEmail) location() Source   { return e.Suffixes.Source }
+
+// InvalidWildcardException reports that an exception to a wildcard
+// suffix does not add exactly one valid label to the wildcard's base
+// domain. For example, given the wildcard "*.example.com",
+// "!foo.example.com" is a valid exception, but "!foo.bar.example.com"
+// is not.
+type InvalidWildcardException struct {
+	Suffixes Suffixes
+	Suffix   Source // the exception
+	Wildcard Source // the wildcard that Suffix is an exception to
+	Label    string // the label(s) Suffix adds to the wildcard's base domain
+}
+
+func (e InvalidWildcardException) Error() string {
+	return fmt.Sprintf("exception %q at %s must add a single label to wildcard %q at %s, not %q", e.Suffix.Text(), e.Suffix.LocationString(), e.Wildcard.Text(), e.Wildcard.LocationString(), e.Label)
+}
+
+func (e InvalidWildcardException) Severity() Severity { return SeverityError }
+func (e InvalidWildcardException) Code() string       { return "invalid_wildcard_exception" }
+func (e InvalidWildcardException) location() Source   { return e.Suffix }
+func (e InvalidWildcardException) suffix() Source     { return e.Suffix }
+func (e InvalidWildcardException) related() []Source  { return []Source{e.Wildcard} }
+
+// EmptyWildcardException reports that an exception suffix is empty,
+// as in a bare "!", or has an empty label, as in "!.example.com".
+type EmptyWildcardException struct {
+	Suffixes Suffixes
+	Suffix   Source
+}
+
+func (e EmptyWildcardException) Error() string {
+	return fmt.Sprintf("exception %q at %s has an empty label", e.Suffix.Text(), e.Suffix.LocationString())
+}
+
+func (e EmptyWildcardException) Severity() Severity { return SeverityError }
+func (e EmptyWildcardException) Code() string       { return "empty_wildcard_exception" }
+func (e EmptyWildcardException) location() Source   { return e.Suffix }
+func (e EmptyWildcardException) suffix() Source     { return e.Suffix }
+
+// OrphanedException reports that an exception suffix is not under
+// any wildcard suffix, so it has no effect. This usually happens when
+// a wildcard is edited or removed and its exceptions are left behind.
+type OrphanedException struct {
+	Suffixes Suffixes
+	Suffix   Source
+}
+
+func (e OrphanedException) Error() string {
+	return fmt.Sprintf("exception %q at %s is not an exception to any wildcard", e.Suffix.Text(), e.Suffix.LocationString())
+}
+
+func (e OrphanedException) Severity() Severity { return SeverityError }
+func (e OrphanedException) Code() string       { return "orphaned_exception" }
+func (e OrphanedException) location() Source   { return e.Suffix }
+func (e OrphanedException) suffix() Source     { return e.Suffix }
+
+// InvalidSuffixLabel reports that a suffix has a label containing
+// characters that are not valid in a hostname, such as underscores
+// or spaces, or a label that starts or ends with a hyphen.
+type InvalidSuffixLabel struct {
+	Suffixes Suffixes
+	Suffix   Source
+	Label    string // the first invalid label of Suffix
+}
+
+func (e InvalidSuffixLabel) Error() string {
+	return fmt.Sprintf("suffix %q at %s has invalid label %q", e.Suffix.Text(), e.Suffix.LocationString(), e.Label)
+}
+
+func (e InvalidSuffixLabel) Severity() Severity { return SeverityError }
+func (e InvalidSuffixLabel) Code() string       { return "invalid_suffix_label" }
+func (e InvalidSuffixLabel) location() Source   { return e.Suffix }
+func (e InvalidSuffixLabel) suffix() Source     { return e.Suffix }
+
+// EmptyLabel reports that a suffix has an empty label, because it
+// starts or ends with a dot, or has two consecutive dots.
+type EmptyLabel struct {
+	Suffixes Suffixes
+	Suffix   Source
+}
+
+func (e EmptyLabel) Error() string {
+	domain := strings.TrimPrefix(e.Suffix.Text(), "*.")
+	what := "an empty label"
+	switch {
+	case strings.HasPrefix(domain, "."):
+		what = "a leading dot"
+	case strings.HasSuffix(domain, "."):
+		what = "a trailing dot"
+	case strings.Contains(domain, ".."):
+		what = "consecutive dots"
+	}
+	return fmt.Sprintf("suffix %q at %s has %s", e.Suffix.Text(), e.Suffix.LocationString(), what)
+}
+
+func (e EmptyLabel) Severity() Severity { return SeverityError }
+func (e EmptyLabel) Code() string       { return "empty_label" }
+func (e EmptyLabel) location() Source   { return e.Suffix }
+func (e EmptyLabel) suffix() Source     { return e.Suffix }
+
+// SuffixCoveredByWildcard reports that a block of suffixes lists a
+// suffix that is already covered by a wildcard in the same block, for
+// example "foo.example.com" and "*.example.com".
+type SuffixCoveredByWildcard struct {
+	Suffixes Suffixes
+	Suffix   Source // the covered suffix
+	Wildcard Source // the wildcard that covers Suffix
+}
+
+func (e SuffixCoveredByWildcard) Error() string {
+	return fmt.Sprintf("suffix %q at %s is already covered by wildcard %q at %s", e.Suffix.Text(), e.Suffix.LocationString(), e.Wildcard.Text(), e.Wildcard.LocationString())
+}
+
+func (e SuffixCoveredByWildcard) Severity() Severity { return SeverityError }
+func (e SuffixCoveredByWildcard) Code() string       { return "suffix_covered_by_wildcard" }
+func (e SuffixCoveredByWildcard) location() Source   { return e.Suffix }
+func (e SuffixCoveredByWildcard) suffix() Source     { return e.Suffix }
+
+// SuffixParentAlreadySuffix reports that a newly added suffix was
+// already a public suffix before it was added, because a wildcard
+// rule on its parent domain in another block covers it. The new
+// entry doesn't change the meaning of the file, and the two blocks
+// disagree about who maintains the suffix.
+type SuffixParentAlreadySuffix struct {
+	Suffixes Suffixes
+	Suffix   Source // the added suffix
+	Rule     Source // the existing wildcard rule that covers Suffix
+	Entity   string // the entity of the block that contains Rule
+}
+
+func (e SuffixParentAlreadySuffix) Error() string {
+	return fmt.Sprintf("added suffix %q at %s is already a public suffix, because its parent is covered by wildcard %q of %q", e.Suffix.Text(), e.Suffix.LocationString(), e.Rule.Text(), e.Entity)
+}
+
+func (e SuffixParentAlreadySuffix) Severity() Severity { return SeverityWarning }
+func (e SuffixParentAlreadySuffix) Code() string       { return "suffix_parent_already_suffix" }
+func (e SuffixParentAlreadySuffix) location() Source   { return e.Suffix }
+func (e SuffixParentAlreadySuffix) suffix() Source     { return e.Suffix }
+
+// SuffixWildcardOverlap reports that a section lists both a wildcard
+// suffix and its base domain, for example "*.example.com" and
+// "example.com".
+type SuffixWildcardOverlap struct {
+	Suffix   Source // the plain suffix
+	Wildcard Source // the wildcard whose base domain is Suffix
+}
+
+func (e SuffixWildcardOverlap) Error() string {
+	return fmt.Sprintf("wildcard %q at %s overlaps with suffix %q at %s", e.Wildcard.Text(), e.Wildcard.LocationString(), e.Suffix.Text(), e.Suffix.LocationString())
+}
+
+func (e SuffixWildcardOverlap) Severity() Severity { return SeverityError }
+func (e SuffixWildcardOverlap) Code() string       { return "suffix_wildcard_overlap" }
+func (e SuffixWildcardOverlap) location() Source   { return e.Wildcard }
+func (e SuffixWildcardOverlap) suffix() Source     { return e.Wildcard }
+func (e SuffixWildcardOverlap) related() []Source  { return []Source{e.Suffix} }
+
+// DuplicateMaintainer reports that the header of a block of suffixes
+// lists the same email address more than once.
+type DuplicateMaintainer struct {
+	Suffixes Suffixes
+	Line     Source // the header line with the repeated address
+	Address  string
+}
+
+func (e DuplicateMaintainer) Error() string {
+	return fmt.Sprintf("email address %q at %s is listed more than once by %s", e.Address, e.Line.LocationString(), e.Suffixes.shortName())
+}
+
+func (e DuplicateMaintainer) Severity() Severity { return SeverityError }
+func (e DuplicateMaintainer) Code() string       { return "duplicate_maintainer" }
+func (e DuplicateMaintainer) location() Source   { return e.Line }
+
+// SuffixTooLong reports that a suffix is longer than the 253 octets
+// allowed by DNS, once converted to punycode.
+type SuffixTooLong struct {
+	Suffixes Suffixes
+	Suffix   Source
+	Length   int // the length of Suffix in punycode form
+}
+
+func (e SuffixTooLong) Error() string {
+	return fmt.Sprintf("suffix %q at %s is %d octets long in punycode, the maximum is 253", e.Suffix.Text(), e.Suffix.LocationString(), e.Length)
+}
+
+func (e SuffixTooLong) Severity() Severity { return SeverityError }
+func (e SuffixTooLong) Code() string       { return "suffix_too_long" }
+func (e SuffixTooLong) location() Source   { return e.Suffix }
+func (e SuffixTooLong) suffix() Source     { return e.Suffix }
+
+// LabelTooLong reports that a label of a suffix is longer than the 63
+// octets allowed by DNS, once converted to punycode.
+type LabelTooLong struct {
+	Suffixes Suffixes
+	Suffix   Source
+	Label    string // the label in punycode form
+}
+
+func (e LabelTooLong) Error() string {
+	return fmt.Sprintf("suffix %q at %s has a label that is %d octets long in punycode, the maximum is 63", e.Suffix.Text(), e.Suffix.LocationString(), len(e.Label))
+}
+
+func (e LabelTooLong) Severity() Severity { return SeverityError }
+func (e LabelTooLong) Code() string       { return "label_too_long" }
+func (e LabelTooLong) location() Source   { return e.Suffix }
+func (e LabelTooLong) suffix() Source     { return e.Suffix }
+
+// ICANNSectionModified reports that a change adds a suffix to the
+// ICANN domains section, or changes the metadata of one. The ICANN
+// section is maintained from IANA and registry data, and is not
+// normally edited by PRs.
+type ICANNSectionModified struct {
+	Suffixes Suffixes
+	Suffix   Source
+}
+
+func (e ICANNSectionModified) Error() string {
+	return fmt.Sprintf("suffix %q at %s in the ICANN domains section was added or modified", e.Suffix.Text(), e.Suffix.LocationString())
+}
+
+func (e ICANNSectionModified) Severity() Severity { return SeverityWarning }
+func (e ICANNSectionModified) Code() string       { return "icann_section_modified" }
+func (e ICANNSectionModified) location() Source   { return e.Suffix }
+func (e ICANNSectionModified) suffix() Source     { return e.Suffix }
+
+// BlockedSuffix reports that a change adds or modifies a suffix that
+// is on a blocklist of domains that must never be accepted.
+type BlockedSuffix struct {
+	Suffixes Suffixes
+	Suffix   Source
+	Blocked  string // the blocklisted domain that Suffix is or is under
+}
+
+func (e BlockedSuffix) Error() string {
+	return fmt.Sprintf("suffix %q at %s is blocked by the blocklist entry %q", e.Suffix.Text(), e.Suffix.LocationString(), e.Blocked)
+}
+
+func (e BlockedSuffix) Severity() Severity { return SeverityError }
+func (e BlockedSuffix) Code() string       { return "blocked_suffix" }
+func (e BlockedSuffix) location() Source   { return e.Suffix }
+func (e BlockedSuffix) suffix() Source     { return e.Suffix }
+
+// ICANNSuffixRemoved reports that a change removes a suffix from the
+// ICANN domains section. Suffix is located in the older file.
+type ICANNSuffixRemoved struct {
+	Suffix Source
+}
+
+func (e ICANNSuffixRemoved) Error() string {
+	return fmt.Sprintf("suffix %q was removed from the ICANN domains section (was at %s)", e.Suffix.Text(), e.Suffix.LocationString())
+}
+
+func (e ICANNSuffixRemoved) Severity() Severity { return SeverityWarning }
+func (e ICANNSuffixRemoved) Code() string       { return "icann_suffix_removed" }
+
+// DanglingException reports that a change removed a suffix, but kept
+// an exception for the same domain, such as "!foo.example.com" after
+// removing "foo.example.com". The exception was probably meant to go
+// with the removed suffix.
+type DanglingException struct {
+	Suffixes  Suffixes
+	Exception Source // the surviving exception, in the newer file
+	Removed   Source // the removed suffix, in the older file
+}
+
+func (e DanglingException) Error() string {
+	return fmt.Sprintf("exception %q at %s refers to suffix %q, which was removed (was at %s)", e.Exception.Text(), e.Exception.LocationString(), e.Removed.Text(), e.Removed.LocationString())
+}
+
+// Severity returns SeverityWarning. ValidateChanges reports
+// DanglingException as an error instead if
+// Options.DanglingExceptionErrors is set.
+func (e DanglingException) Severity() Severity { return SeverityWarning }
+func (e DanglingException) Code() string       { return "dangling_exception" }
+func (e DanglingException) location() Source   { return e.Exception }
+func (e DanglingException) suffix() Source     { return e.Exception }
+
+// InvalidPunycode reports that a suffix has a punycode ("xn--") label
+// that does not decode to a valid Unicode label.
+type InvalidPunycode struct {
+	Suffixes Suffixes
+	Suffix   Source
+	Label    string // the invalid punycode label
+	Decoded  string // what Label decodes to, if it decodes at all
+}
+
+func (e InvalidPunycode) Error() string {
+	if e.Decoded == "" {
+		return fmt.Sprintf("suffix %q at %s has invalid punycode label %q", e.Suffix.Text(), e.Suffix.LocationString(), e.Label)
+	}
+	return fmt.Sprintf("suffix %q at %s has invalid punycode label %q, which decodes to %q", e.Suffix.Text(), e.Suffix.LocationString(), e.Label, e.Decoded)
+}
+
+func (e InvalidPunycode) Severity() Severity { return SeverityError }
+func (e InvalidPunycode) Code() string       { return "invalid_punycode" }
+func (e InvalidPunycode) location() Source   { return e.Suffix }
+func (e InvalidPunycode) suffix() Source     { return e.Suffix }
+
+// InvalidHyphenation reports that a suffix has a label with hyphens
+// in the third and fourth positions that is not a punycode ("xn--")
+// label, which RFC 5891 section 4.2.3.1 forbids.
+type InvalidHyphenation struct {
+	Suffixes Suffixes
+	Suffix   Source
+	Label    string // the first invalid label of Suffix
+}
+
+func (e InvalidHyphenation) Error() string {
+	return fmt.Sprintf("suffix %q at %s has label %q with \"--\" in the third and fourth positions, which RFC 5891 section 4.2.3.1 reserves for punycode (\"xn--\") labels", e.Suffix.Text(), e.Suffix.LocationString(), e.Label)
+}
+
+func (e InvalidHyphenation) Severity() Severity { return SeverityError }
+func (e InvalidHyphenation) Code() string       { return "invalid_hyphenation" }
+func (e InvalidHyphenation) location() Source   { return e.Suffix }
+func (e InvalidHyphenation) suffix() Source     { return e.Suffix }
+
+// TLDInPrivateSection reports that a suffix in the private domains
+// section is a single label, or a wildcard of a single label.
+type TLDInPrivateSection struct {
+	Suffixes Suffixes
+	Suffix   Source
+}
+
+func (e TLDInPrivateSection) Error() string {
+	return fmt.Sprintf("suffix %q at %s is a TLD and cannot be in the private domains section", e.Suffix.Text(), e.Suffix.LocationString())
+}
+
+func (e TLDInPrivateSection) Severity() Severity { return SeverityError }
+func (e TLDInPrivateSection) Code() string       { return "tld_in_private_section" }
+func (e TLDInPrivateSection) location() Source   { return e.Suffix }
+func (e TLDInPrivateSection) suffix() Source     { return e.Suffix }
+
+// SuffixInWrongSection reports that a block of suffixes in the ICANN
+// domains section looks like it belongs in the private domains
+// section, because it only lists suffixes under TLDs that belong to
+// other blocks. This is a heuristic, so it is only a warning.
+type SuffixInWrongSection struct {
+	Suffixes Suffixes
+	Owner    Suffixes // a block that lists the TLD of Suffixes
+}
+
+func (e SuffixInWrongSection) Error() string {
+	return fmt.Sprintf("%s at %s only has suffixes under TLDs of other blocks such as %s at %s, it may belong in the private domains section", e.Suffixes.shortName(), e.Suffixes.LocationString(), e.Owner.shortName(), e.Owner.LocationString())
+}
+
+func (e SuffixInWrongSection) Severity() Severity { return SeverityWarning }
+func (e SuffixInWrongSection) Code() string       { return "suffix_in_wrong_section" }
+func (e SuffixInWrongSection) location() Source   { return e.Suffixes.Source }
+func (e SuffixInWrongSection) related() []Source  { return []Source{e.Owner.Source} }
+
+// SharedMaintainer reports that several blocks of suffixes with
+// different entity names have the same contact email address. This is
+// a heuristic for reviewers, so it is only a warning.
+type SharedMaintainer struct {
+	Address  string
+	Entities []Suffixes // the blocks using Address, one per entity name
+}
+
+func (e SharedMaintainer) Error() string {
+	var entities []string
+	for _, block := range e.Entities {
+		entities = append(entities, fmt.Sprintf("%s at %s", block.shortName(), block.LocationString()))
+	}
+	return fmt.Sprintf("contact email %q is shared by different entities: %s", e.Address, strings.Join(entities, ", "))
+}
+
+func (e SharedMaintainer) Severity() Severity { return SeverityWarning }
+func (e SharedMaintainer) Code() string       { return "shared_maintainer" }
+
+// location returns the last block, which is usually the most recent
+// submission.
+func (e SharedMaintainer) location() Source { return e.Entities[len(e.Entities)-1].Source }
+
+func (e SharedMaintainer) related() []Source {
+	var ret []Source
+	for _, block := range e.Entities {
+		ret = append(ret, block.Source)
+	}
+	return ret
+}
+
+// EmptyEntity reports that a top-level comment in the private domains
+// section looks like the header of a block of suffixes, but has no
+// suffixes.
+type EmptyEntity struct {
+	Comment Comment
+}
+
+func (e EmptyEntity) Error() string {
+	return fmt.Sprintf("comment at %s looks like the header of an entity, but lists no suffixes", e.Comment.LocationString())
+}
+
+func (e EmptyEntity) Severity() Severity { return SeverityError }
+func (e EmptyEntity) Code() string       { return "empty_entity" }
+func (e EmptyEntity) location() Source   { return e.Comment.Source }
+
+// PossiblyMergedEntities reports that a block of suffixes has a
+// comment between its suffixes that looks like the header of a
+// separate block, which suggests that two blocks are missing the
+// blank line between them. This is a heuristic, so it is only a
+// warning.
+type PossiblyMergedEntities struct {
+	Suffixes Suffixes
+	Line     Source // the header-like comment line
+}
+
+func (e PossiblyMergedEntities) Error() string {
+	return fmt.Sprintf("comment %q at %s in %s looks like the header of another block, is a blank line missing before it?", e.Line.Text(), e.Line.LocationString(), e.Suffixes.shortName())
+}
+
+func (e PossiblyMergedEntities) Severity() Severity { return SeverityWarning }
+func (e PossiblyMergedEntities) Code() string       { return "possibly_merged_entities" }
+func (e PossiblyMergedEntities) location() Source   { return e.Line }
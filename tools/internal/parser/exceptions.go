@@ -1,6 +1,10 @@
 package parser
 
-import "strings"
+import (
+	_ "embed"
+	"slices"
+	"strings"
+)
 
 // Exceptions are blocks of the PSL that would fail current validation
 // and stylistic requirements, but are exempted due to predating those
@@ -13,7 +17,8 @@ import "strings"
 // conformant with current policy, while not requiring that all
 // existing lint be fixed immediately.
 //
-// See the bottom of this file for the exceptions themselves.
+// See the bottom of this file, and missing_email.txt, for the
+// exceptions themselves.
 
 // downgradeToWarning reports whether e is a legacy exception to
 // normal parsing and validation rules, and should be reported as a
@@ -21,7 +26,13 @@ import "strings"
 func downgradeToWarning(e error) bool {
 	switch v := e.(type) {
 	case MissingEntityEmail:
-		return sourceIsExempted(missingEmail, v.Suffixes.Text())
+		return IsExemptFromContactInfo(v.Suffixes)
+	case MissingSubmissionReference:
+		return sourceIsExempted(missingSubmissionReference, v.Suffixes.Text())
+	case EmptyEntity:
+		return sourceIsExempted(emptyEntity, v.Comment.Text())
+	case DuplicateEntityName:
+		return sourceIsExempted(duplicateEntityName, v.Suffixes.Text()) && sourceIsExempted(duplicateEntityName, v.Previous.Text())
 	}
 	return false
 }
@@ -40,353 +51,131 @@ func lines(lines ...string) string {
 }
 
 // missingEmail are source code blocks in the private domains section
-// that are allowed to lack email contact information.
-var missingEmail = []string{
-	lines(
-		"// 611coin : https://611project.org/",
-		"611.to",
-	),
-	lines(
-		"// c.la : http://www.c.la/",
-		"c.la",
-	),
-	lines(
-		"// co.ca : http://registry.co.ca/",
-		"co.ca",
-	),
-	lines(
-		"// DynDNS.com : http://www.dyndns.com/services/dns/dyndns/",
-		"dyndns.biz",
-		"for-better.biz",
-		"for-more.biz",
-		"for-some.biz",
-		"for-the.biz",
-		"selfip.biz",
-		"webhop.biz",
-		"ftpaccess.cc",
-		"game-server.cc",
-		"myphotos.cc",
-		"scrapping.cc",
-		"blogdns.com",
-		"cechire.com",
-		"dnsalias.com",
-		"dnsdojo.com",
-		"doesntexist.com",
-		"dontexist.com",
-		"doomdns.com",
-		"dyn-o-saur.com",
-		"dynalias.com",
-		"dyndns-at-home.com",
-		"dyndns-at-work.com",
-		"dyndns-blog.com",
-		"dyndns-free.com",
-		"dyndns-home.com",
-		"dyndns-ip.com",
-		"dyndns-mail.com",
-		"dyndns-office.com",
-		"dyndns-pics.com",
-		"dyndns-remote.com",
-		"dyndns-server.com",
-		"dyndns-web.com",
-		"dyndns-wiki.com",
-		"dyndns-work.com",
-		"est-a-la-maison.com",
-		"est-a-la-masion.com",
-		"est-le-patron.com",
-		"est-mon-blogueur.com",
-		"from-ak.com",
-		"from-al.com",
-		"from-ar.com",
-		"from-ca.com",
-		"from-ct.com",
-		"from-dc.com",
-		"from-de.com",
-		"from-fl.com",
-		"from-ga.com",
-		"from-hi.com",
-		"from-ia.com",
-		"from-id.com",
-		"from-il.com",
-		"from-in.com",
-		"from-ks.com",
-		"from-ky.com",
-		"from-ma.com",
-		"from-md.com",
-		"from-mi.com",
-		"from-mn.com",
-		"from-mo.com",
-		"from-ms.com",
-		"from-mt.com",
-		"from-nc.com",
-		"from-nd.com",
-		"from-ne.com",
-		"from-nh.com",
-		"from-nj.com",
-		"from-nm.com",
-		"from-nv.com",
-		"from-oh.com",
-		"from-ok.com",
-		"from-or.com",
-		"from-pa.com",
-		"from-pr.com",
-		"from-ri.com",
-		"from-sc.com",
-		"from-sd.com",
-		"from-tn.com",
-		"from-tx.com",
-		"from-ut.com",
-		"from-va.com",
-		"from-vt.com",
-		"from-wa.com",
-		"from-wi.com",
-		"from-wv.com",
-		"from-wy.com",
-		"getmyip.com",
-		"gotdns.com",
-		"hobby-site.com",
-		"homelinux.com",
-		"homeunix.com",
-		"iamallama.com",
-		"is-a-anarchist.com",
-		"is-a-blogger.com",
-		"is-a-bookkeeper.com",
-		"is-a-bulls-fan.com",
-		"is-a-caterer.com",
-		"is-a-chef.com",
-		"is-a-conservative.com",
-		"is-a-cpa.com",
-		"is-a-cubicle-slave.com",
-		"is-a-democrat.com",
-		"is-a-designer.com",
-		"is-a-doctor.com",
-		"is-a-financialadvisor.com",
-		"is-a-geek.com",
-		"is-a-green.com",
-		"is-a-guru.com",
-		"is-a-hard-worker.com",
-		"is-a-hunter.com",
-		"is-a-landscaper.com",
-		"is-a-lawyer.com",
-		"is-a-liberal.com",
-		"is-a-libertarian.com",
-		"is-a-llama.com",
-		"is-a-musician.com",
-		"is-a-nascarfan.com",
-		"is-a-nurse.com",
-		"is-a-painter.com",
-		"is-a-personaltrainer.com",
-		"is-a-photographer.com",
-		"is-a-player.com",
-		"is-a-republican.com",
-		"is-a-rockstar.com",
-		"is-a-socialist.com",
-		"is-a-student.com",
-		"is-a-teacher.com",
-		"is-a-techie.com",
-		"is-a-therapist.com",
-		"is-an-accountant.com",
-		"is-an-actor.com",
-		"is-an-actress.com",
-		"is-an-anarchist.com",
-		"is-an-artist.com",
-		"is-an-engineer.com",
-		"is-an-entertainer.com",
-		"is-certified.com",
-		"is-gone.com",
-		"is-into-anime.com",
-		"is-into-cars.com",
-		"is-into-cartoons.com",
-		"is-into-games.com",
-		"is-leet.com",
-		"is-not-certified.com",
-		"is-slick.com",
-		"is-uberleet.com",
-		"is-with-theband.com",
-		"isa-geek.com",
-		"isa-hockeynut.com",
-		"issmarterthanyou.com",
-		"likes-pie.com",
-		"likescandy.com",
-		"neat-url.com",
-		"saves-the-whales.com",
-		"selfip.com",
-		"sells-for-less.com",
-		"sells-for-u.com",
-		"servebbs.com",
-		"simple-url.com",
-		"space-to-rent.com",
-		"teaches-yoga.com",
-		"writesthisblog.com",
-		"ath.cx",
-		"fuettertdasnetz.de",
-		"isteingeek.de",
-		"istmein.de",
-		"lebtimnetz.de",
-		"leitungsen.de",
-		"traeumtgerade.de",
-		"barrel-of-knowledge.info",
-		"barrell-of-knowledge.info",
-		"dyndns.info",
-		"for-our.info",
-		"groks-the.info",
-		"groks-this.info",
-		"here-for-more.info",
-		"knowsitall.info",
-		"selfip.info",
-		"webhop.info",
-		"forgot.her.name",
-		"forgot.his.name",
-		"at-band-camp.net",
-		"blogdns.net",
-		"broke-it.net",
-		"buyshouses.net",
-		"dnsalias.net",
-		"dnsdojo.net",
-		"does-it.net",
-		"dontexist.net",
-		"dynalias.net",
-		"dynathome.net",
-		"endofinternet.net",
-		"from-az.net",
-		"from-co.net",
-		"from-la.net",
-		"from-ny.net",
-		"gets-it.net",
-		"ham-radio-op.net",
-		"homeftp.net",
-		"homeip.net",
-		"homelinux.net",
-		"homeunix.net",
-		"in-the-band.net",
-		"is-a-chef.net",
-		"is-a-geek.net",
-		"isa-geek.net",
-		"kicks-ass.net",
-		"office-on-the.net",
-		"podzone.net",
-		"scrapper-site.net",
-		"selfip.net",
-		"sells-it.net",
-		"servebbs.net",
-		"serveftp.net",
-		"thruhere.net",
-		"webhop.net",
-		"merseine.nu",
-		"mine.nu",
-		"shacknet.nu",
-		"blogdns.org",
-		"blogsite.org",
-		"boldlygoingnowhere.org",
-		"dnsalias.org",
-		"dnsdojo.org",
-		"doesntexist.org",
-		"dontexist.org",
-		"doomdns.org",
-		"dvrdns.org",
-		"dynalias.org",
-		"dyndns.org",
-		"go.dyndns.org",
-		"home.dyndns.org",
-		"endofinternet.org",
-		"endoftheinternet.org",
-		"from-me.org",
-		"game-host.org",
-		"gotdns.org",
-		"hobby-site.org",
-		"homedns.org",
-		"homeftp.org",
-		"homelinux.org",
-		"homeunix.org",
-		"is-a-bruinsfan.org",
-		"is-a-candidate.org",
-		"is-a-celticsfan.org",
-		"is-a-chef.org",
-		"is-a-geek.org",
-		"is-a-knight.org",
-		"is-a-linux-user.org",
-		"is-a-patsfan.org",
-		"is-a-soxfan.org",
-		"is-found.org",
-		"is-lost.org",
-		"is-saved.org",
-		"is-very-bad.org",
-		"is-very-evil.org",
-		"is-very-good.org",
-		"is-very-nice.org",
-		"is-very-sweet.org",
-		"isa-geek.org",
-		"kicks-ass.org",
-		"misconfused.org",
-		"podzone.org",
-		"readmyblog.org",
-		"selfip.org",
-		"sellsyourhome.org",
-		"servebbs.org",
-		"serveftp.org",
-		"servegame.org",
-		"stuff-4-sale.org",
-		"webhop.org",
-		"better-than.tv",
-		"dyndns.tv",
-		"on-the-web.tv",
-		"worse-than.tv",
-		"is-by.us",
-		"land-4-sale.us",
-		"stuff-4-sale.us",
-		"dyndns.ws",
-		"mypets.ws",
-	),
-	lines(
-		"// Hashbang : https://hashbang.sh",
-		"hashbang.sh",
-	),
-	lines(
-		"// HostyHosting (https://hostyhosting.com)",
-		"hostyhosting.io",
-	),
-	lines(
-		"// info.at : http://www.info.at/",
-		"biz.at",
-		"info.at",
-	),
-	lines(
-		"// .KRD : http://nic.krd/data/krd/Registration%20Policy.pdf",
-		"co.krd",
-		"edu.krd",
-	),
+// that are allowed to lack email contact information. They are kept
+// in missing_email.txt, in the same form as in the PSL, separated by
+// blank lines.
+var missingEmail = splitExemptions(missingEmailText)
+
+//go:embed missing_email.txt
+var missingEmailText string
+
+// splitExemptions splits text into blank line separated blocks.
+func splitExemptions(text string) []string {
+	var ret []string
+	for _, block := range strings.Split(strings.TrimSpace(text), "\n\n") {
+		ret = append(ret, strings.TrimSpace(block))
+	}
+	return ret
+}
+
+// ContactInfoExemptions returns the source text of the blocks of
+// suffixes that are exempt from the requirement to provide email
+// contact information. Options.ContactInfoExemptions can exempt more
+// blocks for a single parse.
+func ContactInfoExemptions() []string {
+	return slices.Clone(missingEmail)
+}
+
+// IsExemptFromContactInfo reports whether block is exempt from the
+// requirement to provide email contact information.
+func IsExemptFromContactInfo(block Suffixes) bool {
+	return sourceIsExempted(missingEmail, block.Text())
+}
+
+// exemptByOptions reports whether e is exempted by the extra
+// exemptions in p's Options, and should be reported as a warning like
+// the built-in exemptions of downgradeToWarning.
+func (p *parser) exemptByOptions(e error) bool {
+	v, ok := e.(MissingEntityEmail)
+	return ok && sourceIsExempted(p.opts.ContactInfoExemptions, v.Suffixes.Text())
+}
+
+// duplicateEntityName are source code blocks in the private domains
+// section that are allowed to share their entity name with another
+// block. Both blocks of a pair must be listed.
+var duplicateEntityName = []string{
 	lines(
-		"// Michau Enterprises Limited : http://www.co.pl/",
-		"co.pl",
+		"// MetaCentrum, CESNET z.s.p.o. : https://www.metacentrum.cz/en/",
+		"// Submitted by Zdeněk Šustr <zdenek.sustr@cesnet.cz>",
+		"*.cloud.metacentrum.cz",
+		"custom.metacentrum.cz",
 	),
 	lines(
-		"// Nicolaus Copernicus University in Torun - MSK TORMAN (https://www.man.torun.pl)",
-		"torun.pl",
+		"// MetaCentrum, CESNET z.s.p.o. : https://www.metacentrum.cz/en/",
+		"// Submitted by Radim Janča <janca@cesnet.cz>",
+		"flt.cloud.muni.cz",
+		"usr.cloud.muni.cz",
 	),
 	lines(
-		"// TASK geographical domains (https://www.task.gda.pl/uslugi/dns)",
-		"gda.pl",
-		"gdansk.pl",
-		"gdynia.pl",
-		"med.pl",
-		"sopot.pl",
+		"// TransIP : https://www.transip.nl",
+		"// Submitted by Rory Breuk <rbreuk@transip.nl>",
+		"*.transurl.be",
+		"*.transurl.eu",
+		"*.transurl.nl",
 	),
 	lines(
-		"// CoDNS B.V.",
-		"co.nl",
-		"co.no",
+		"// TransIP: https://www.transip.nl",
+		"// Submitted by Cedric Dubois <cedric.dubois@team.blue>",
+		"site.transip.me",
 	),
+}
+
+// emptyEntity are top-level comments in the private domains section
+// that are allowed to look like the header of a block of suffixes.
+var emptyEntity = []string{
+	// Introduces the blocks of Amazon's subsidiaries that follow it.
 	lines(
-		"// .pl domains (grandfathered)",
-		"art.pl",
-		"gliwice.pl",
-		"krakow.pl",
-		"poznan.pl",
-		"wroc.pl",
-		"zakopane.pl",
+		"// Amazon : https://www.amazon.com/",
+		"// Submitted by AWS Security <psl-maintainers@amazon.com>",
+		"// Subsections of Amazon/subsidiaries will appear until \"concludes\" tag",
 	),
+}
+
+// freeEmailProviders are email domains that anyone can get an address
+// at. Submitters using these are exempt from the check that their
+// email address is related to the suffixes they submit.
+var freeEmailProviders = []string{
+	"gmail.com",
+	"googlemail.com",
+	"hotmail.com",
+	"icloud.com",
+	"live.com",
+	"me.com",
+	"outlook.com",
+	"proton.me",
+	"protonmail.com",
+	"qq.com",
+	"yahoo.com",
+	"yandex.ru",
+}
+
+// freeEmailMaintainerEntities are the entity names of private blocks
+// whose submitters were checked to really represent the entity, even
+// though they use an address at one of the freeEmailProviders. They
+// are not warned about by warnFreeEmailMaintainers.
+var freeEmailMaintainerEntities = []string{}
+
+// missingSubmissionReference are source code blocks in the private
+// domains section that are allowed to lack a "Submitted by" line or
+// pull request link.
+var missingSubmissionReference = []string{
 	lines(
-		"// QA2",
-		"// Submitted by Daniel Dent (https://www.danieldent.com/)",
-		"qa2.com",
+		"// Future Versatile Group. : https://www.fvg-on.net/",
+		"// T.Kabu <webmaster@fvg-on.net>",
+		"daemon.asia",
+		"dix.asia",
+		"mydns.bz",
+		"0am.jp",
+		"0g0.jp",
+		"0j0.jp",
+		"0t0.jp",
+		"mydns.jp",
+		"pgw.jp",
+		"wjg.jp",
+		"keyword-on.net",
+		"live-on.net",
+		"server-on.net",
+		"mydns.tw",
+		"mydns.vc",
 	),
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/mail"
 	"net/url"
+	"strings"
 )
 
 // File is a parsed PSL file.
@@ -23,7 +24,15 @@ type File struct {
 	// have validation errors, due to PSL policy changes. As long as
 	// the entries in question don't change, their preexisting
 	// validation errors are downgraded to lint warnings.
+	//
+	// Warnings also include lint findings whose Severity is
+	// SeverityWarning, such as not using https URLs in block headers.
 	Warnings []error
+
+	// blankLines is the number of blank lines before each block in
+	// the parsed input, keyed by the block's line offset. Format uses
+	// it to reproduce the spacing of the input.
+	blankLines map[int]int
 }
 
 // AllSuffixBlocks returns all suffix blocks in f.
@@ -40,6 +49,38 @@ func (f *File) AllSuffixBlocks() []Suffixes {
 	return ret
 }
 
+// ExceptionsFor returns the labels of the exceptions to the wildcard
+// "*.base" in f, in the order they appear. For example, given
+// "*.example.com", "!foo.example.com" and "!bar.example.com",
+// ExceptionsFor("example.com") returns ["foo", "bar"]. It returns nil
+// if f has no such wildcard, or the wildcard has no exceptions.
+//
+// Exceptions that add more than one label to base are invalid (see
+// InvalidWildcardException), and are not returned.
+func (f *File) ExceptionsFor(base string) []string {
+	hasWildcard := false
+	var ret []string
+
+	for _, block := range f.AllSuffixBlocks() {
+		for _, entry := range block.Entries {
+			text := entry.Text()
+			if text == "*."+base {
+				hasWildcard = true
+			} else if exc, ok := strings.CutPrefix(text, "!"); ok {
+				label, parent, ok := strings.Cut(exc, ".")
+				if ok && label != "" && parent == base {
+					ret = append(ret, label)
+				}
+			}
+		}
+	}
+
+	if !hasWildcard {
+		return nil
+	}
+	return ret
+}
+
 // SuffixBlocksInSection returns all suffix blocks within the named
 // file section (for example, "ICANN DOMAINS" or "PRIVATE DOMAINS").
 func (f *File) SuffixBlocksInSection(name string) []Suffixes {
@@ -178,3 +219,17 @@ func (s Suffixes) shortName() string {
 	}
 	return fmt.Sprintf("%d unowned suffixes", len(s.Entries))
 }
+
+// SubmissionReference returns the first header line of s that records
+// how s was submitted: either a "Submitted by" line, or a link to a
+// pull request or issue in the PSL's GitHub repository. It returns
+// false if the header has no such line.
+func (s Suffixes) SubmissionReference() (Source, bool) {
+	for _, line := range s.Header {
+		text := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(line.Text(), "//")))
+		if strings.Contains(text, submittedBy) || strings.Contains(text, "github.com/publicsuffix/list/pull/") || strings.Contains(text, "github.com/publicsuffix/list/issues/") {
+			return line, true
+		}
+	}
+	return Source{}, false
+}
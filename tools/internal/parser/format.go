@@ -0,0 +1,359 @@
+package parser
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"net/mail"
+	"slices"
+	"strings"
+)
+
+// Format returns f as PSL source text.
+//
+// Blocks are separated by as many blank lines as in the parsed input,
+// so formatting a parsed file reproduces it byte for byte. Blocks
+// that were adjacent in the input (for example a section marker
+// immediately followed by a comment) are kept adjacent, and other
+// blocks, such as ones added programmatically, are separated by a
+// single blank line. Suffix blocks are written out
+// from their Header, Entries and InlineComments fields, so
+// programmatic edits to those fields are reflected in the output. The
+// output ends with a single newline.
+//
+// Formatting is idempotent: parsing and formatting the output of
+// Format produces byte-identical results. Use FormatCanonical to
+// also normalize the spacing between blocks.
+func Format(f *File) []byte {
+	return format(f, false)
+}
+
+// FormatCanonical returns f as PSL source text in canonical layout.
+// It is like Format, except that the spacing of the input is not
+// kept: there are no blank lines before the first block, blocks that
+// were adjacent in the input stay adjacent, and all other blocks are
+// separated by exactly one blank line.
+func FormatCanonical(f *File) []byte {
+	return format(f, true)
+}
+
+// format implements Format and FormatCanonical.
+func format(f *File, canonical bool) []byte {
+	var ret bytes.Buffer
+	end := 0 // the line after the previous block in the input
+	for i, block := range f.Blocks {
+		src := block.source()
+		blank, ok := f.blankLines[src.lineOffset]
+		if !ok || canonical {
+			switch {
+			case i == 0 || src.lineOffset == end:
+				blank = 0
+			default:
+				blank = 1
+			}
+		}
+		for j := 0; j < blank; j++ {
+			ret.WriteByte('\n')
+		}
+		end = src.lineOffset + len(src.lines)
+
+		for _, line := range blockLines(block) {
+			ret.WriteString(line)
+			ret.WriteByte('\n')
+		}
+	}
+	return ret.Bytes()
+}
+
+// blockLines returns the lines of source text for block.
+func blockLines(block Block) []string {
+	s, ok := block.(Suffixes)
+	if !ok {
+		return block.source().lines
+	}
+
+	var srcs []Source
+	srcs = append(srcs, s.Header...)
+	srcs = append(srcs, s.Entries...)
+	srcs = append(srcs, s.InlineComments...)
+	slices.SortStableFunc(srcs, func(a, b Source) int {
+		return cmp.Compare(a.lineOffset, b.lineOffset)
+	})
+
+	var ret []string
+	for _, src := range srcs {
+		ret = append(ret, src.lines...)
+	}
+	return ret
+}
+
+// SortSuffixes sorts the suffixes of every block in the private
+// domains section of f into canonical order, in place.
+//
+// Like the validation that reports unsorted suffixes, each run of
+// suffixes between inline comments is sorted independently. Use
+// Format to write out the result.
+func SortSuffixes(f *File) {
+	var curSection string
+	for i, block := range f.Blocks {
+		switch v := block.(type) {
+		case StartSection:
+			curSection = v.Name
+		case EndSection:
+			curSection = ""
+		case Suffixes:
+			if curSection != "PRIVATE DOMAINS" {
+				continue
+			}
+			entries := make([]Source, 0, len(v.Entries))
+			for _, run := range entryRuns(v.Entries) {
+				sorted := slices.Clone(run)
+				slices.SortStableFunc(sorted, func(a, b Source) int {
+					return compareEntries(a.Text(), b.Text())
+				})
+				// Keep the line positions of the run, so that the
+				// suffixes stay in the same place relative to
+				// inline comments.
+				for j := range sorted {
+					sorted[j].lineOffset = run[j].lineOffset
+				}
+				entries = append(entries, sorted...)
+			}
+			v.Entries = entries
+			f.Blocks[i] = v
+		}
+	}
+}
+
+// SortExceptions sorts the exceptions to every wildcard in f into
+// canonical order within their block, in place. Unlike SortSuffixes,
+// it applies to all sections, and only exceptions move: each one
+// takes the line of an exception to the same wildcard. Use Format to
+// write out the result.
+func SortExceptions(f *File) {
+	for i, block := range f.Blocks {
+		v, ok := block.(Suffixes)
+		if !ok {
+			continue
+		}
+		groups := wildcardExceptions(v.Entries)
+		if len(groups) == 0 {
+			continue
+		}
+		entries := slices.Clone(v.Entries)
+		for _, group := range groups {
+			sorted := make([]Source, 0, len(group.exceptions))
+			for _, j := range group.exceptions {
+				sorted = append(sorted, v.Entries[j])
+			}
+			slices.SortStableFunc(sorted, func(a, b Source) int {
+				return compareEntries(a.Text(), b.Text())
+			})
+			for k, j := range group.exceptions {
+				sorted[k].lineOffset = v.Entries[j].lineOffset
+				entries[j] = sorted[k]
+			}
+		}
+		v.Entries = entries
+		f.Blocks[i] = v
+	}
+}
+
+// NormalizeMetadata rewrites the header of every block in the private
+// domains section of f into the canonical form, in place:
+//
+//	// <entity name> : <url>
+//	// Submitted by <name> <email>
+//
+// Header lines that don't contribute to the block's Entity, URL or
+// Submitter are kept, in their original order, after the canonical
+// lines. Blocks that lack an entity name, URL or submitter are left
+// unchanged, since they cannot be written in canonical form. Use
+// Format to write out the result.
+func NormalizeMetadata(f *File) {
+	var curSection string
+	for i, block := range f.Blocks {
+		switch v := block.(type) {
+		case StartSection:
+			curSection = v.Name
+		case EndSection:
+			curSection = ""
+		case Suffixes:
+			if curSection != "PRIVATE DOMAINS" || v.Entity == "" || v.URL == nil || v.Submitter == nil {
+				continue
+			}
+
+			lines := []string{
+				"// " + v.Entity + " : " + v.URL.String(),
+				"// Submitted by " + submitterString(v.Submitter),
+			}
+			for _, line := range v.Header {
+				if !isMetadataLine(v, line.Text()) {
+					lines = append(lines, line.Text())
+				}
+			}
+			if len(lines) > len(v.Header) {
+				// Can't happen for parser output, the entity, URL and
+				// submitter come from at least 2 lines. Bail rather
+				// than make up line positions for the new header.
+				continue
+			}
+
+			header := make([]Source, 0, len(lines))
+			for j, line := range lines {
+				header = append(header, Source{
+					lines:      []string{line},
+					lineOffset: v.Header[j].lineOffset,
+					path:       v.Header[j].path,
+				})
+			}
+			v.Header = header
+			f.Blocks[i] = v
+		}
+	}
+}
+
+// MergeSuffixes combines two blocks of suffixes that belong to the
+// same entity into one, for example to fix a block that was
+// accidentally split in two.
+//
+// The result has the header of a followed by the header lines of b
+// that don't repeat a's metadata, then the suffixes of a followed by
+// those of b that are not already in a, along with b's inline
+// comments. Suffixes are compared in canonical form, like when
+// checking for duplicate suffixes. The merged block's lines are
+// numbered from the start of a, and its Source holds the merged text,
+// so it is meant to replace a in its File, with b removed. Lines that
+// moved have no byte offsets, since they are no longer where the
+// input had them. Use SortSuffixes and Format to write out the
+// result.
+//
+// MergeSuffixes returns an error if a and b have different entity
+// names, URLs or contact email addresses. Metadata that is missing
+// from a is taken from b.
+func MergeSuffixes(a, b Suffixes) (Suffixes, error) {
+	if a.Entity != "" && b.Entity != "" && !strings.EqualFold(strings.Join(strings.Fields(a.Entity), " "), strings.Join(strings.Fields(b.Entity), " ")) {
+		return Suffixes{}, fmt.Errorf("cannot merge blocks with different entity names %q and %q", a.Entity, b.Entity)
+	}
+	if a.URL != nil && b.URL != nil && a.URL.String() != b.URL.String() {
+		return Suffixes{}, fmt.Errorf("cannot merge blocks for %q with different URLs %q and %q", a.Entity, a.URL, b.URL)
+	}
+	if a.Submitter != nil && b.Submitter != nil && !strings.EqualFold(a.Submitter.Address, b.Submitter.Address) {
+		return Suffixes{}, fmt.Errorf("cannot merge blocks for %q with different contact emails %q and %q", a.Entity, a.Submitter.Address, b.Submitter.Address)
+	}
+
+	ret := a
+	if ret.Entity == "" {
+		ret.Entity = b.Entity
+	}
+	if ret.URL == nil {
+		ret.URL = b.URL
+	}
+	if ret.Submitter == nil {
+		ret.Submitter = b.Submitter
+	}
+
+	// Lines are renumbered from the start of a, so that Format writes
+	// them in the merged order.
+	next := a.lineOffset
+	move := func(src Source) Source {
+		if src.lineOffset != next {
+			src.lineOffset = next
+			src.offsets = nil
+		}
+		next += len(src.lines)
+		return src
+	}
+
+	ret.Header = nil
+	for _, line := range a.Header {
+		ret.Header = append(ret.Header, move(line))
+	}
+	hasMetadata := a.Entity != "" && a.URL != nil && a.Submitter != nil
+	for _, line := range b.Header {
+		dup := slices.ContainsFunc(a.Header, func(h Source) bool { return h.Text() == line.Text() })
+		if !dup && !(hasMetadata && isMetadataLine(a, line.Text())) {
+			ret.Header = append(ret.Header, move(line))
+		}
+	}
+
+	seen := map[string]bool{}
+	key := func(entry Source) string {
+		canon, err := canonicalEntry(entry.Text())
+		if err != nil {
+			return strings.ToLower(entry.Text())
+		}
+		return canon
+	}
+	type bodyLine struct {
+		src     Source
+		comment bool
+	}
+	ret.Entries, ret.InlineComments = nil, nil
+	for _, block := range []Suffixes{a, b} {
+		// Walk the suffixes and inline comments together, so that
+		// they stay in the same order relative to each other.
+		var body []bodyLine
+		for _, entry := range block.Entries {
+			body = append(body, bodyLine{entry, false})
+		}
+		for _, comment := range block.InlineComments {
+			body = append(body, bodyLine{comment, true})
+		}
+		slices.SortStableFunc(body, func(x, y bodyLine) int {
+			return cmp.Compare(x.src.lineOffset, y.src.lineOffset)
+		})
+		for _, line := range body {
+			if line.comment {
+				ret.InlineComments = append(ret.InlineComments, move(line.src))
+				continue
+			}
+			if k := key(line.src); !seen[k] {
+				seen[k] = true
+				ret.Entries = append(ret.Entries, move(line.src))
+			}
+		}
+	}
+
+	ret.Source = Source{
+		lines:      blockLines(ret),
+		lineOffset: a.lineOffset,
+		path:       a.path,
+	}
+	return ret, nil
+}
+
+// isMetadataLine reports whether the header line text provides the
+// Entity, URL or Submitter of s.
+func isMetadataLine(s Suffixes, text string) bool {
+	if strings.HasPrefix(text, sectionMarkerPrefix) {
+		return false
+	}
+	line := strings.TrimSpace(strings.TrimPrefix(text, "//"))
+
+	if line == s.Entity {
+		return true
+	}
+	if name, u, addr := splitNameish(line); name == s.Entity {
+		return (u == nil || u.String() == s.URL.String()) && (addr == nil || *addr == *s.Submitter)
+	}
+	if u := getURL(line); u != nil && u.String() == s.URL.String() {
+		return true
+	}
+	if addr := getSubmitter(line); addr != nil && *addr == *s.Submitter {
+		return true
+	}
+	if addr, err := mail.ParseAddress(line); err == nil && *addr == *s.Submitter {
+		return true
+	}
+	return false
+}
+
+// submitterString returns addr in the form used by PSL headers. Unlike
+// addr.String, it does not quote or encode the name.
+func submitterString(addr *mail.Address) string {
+	if addr.Name == "" {
+		return "<" + addr.Address + ">"
+	}
+	return addr.Name + " <" + addr.Address + ">"
+}
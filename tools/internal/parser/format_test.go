@@ -0,0 +1,520 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"net/mail"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   []byte
+		want []byte
+	}{
+		{
+			name: "empty",
+			in:   byteLines(""),
+			want: byteLines(""),
+		},
+		{
+			name: "canonical",
+			in: byteLines(
+				"// ===BEGIN PRIVATE DOMAINS===",
+				"",
+				"// DuckCorp Inc: https://example.com",
+				"// Submitted by Not A Duck <duck@example.com>",
+				"example.com",
+				"// Inline comment",
+				"example.org",
+				"",
+				"// ===END PRIVATE DOMAINS===",
+				"",
+			),
+			want: byteLines(
+				"// ===BEGIN PRIVATE DOMAINS===",
+				"",
+				"// DuckCorp Inc: https://example.com",
+				"// Submitted by Not A Duck <duck@example.com>",
+				"example.com",
+				"// Inline comment",
+				"example.org",
+				"",
+				"// ===END PRIVATE DOMAINS===",
+				"",
+			),
+		},
+		{
+			name: "extra_blank_lines",
+			in: byteLines(
+				"",
+				"// Top-level comment",
+				"",
+				"",
+				"",
+				"// DuckCorp Inc: https://example.com",
+				"example.com",
+				"",
+				"",
+			),
+			// Blank lines between blocks are kept, only the
+			// trailing ones are trimmed to a single newline.
+			want: byteLines(
+				"",
+				"// Top-level comment",
+				"",
+				"",
+				"",
+				"// DuckCorp Inc: https://example.com",
+				"example.com",
+				"",
+			),
+		},
+		{
+			name: "adjacent_section_markers",
+			in: byteLines(
+				"// ===BEGIN ICANN DOMAINS===",
+				"// ===END ICANN DOMAINS===",
+				"// ===BEGIN PRIVATE DOMAINS===",
+				"// A comment right after a marker",
+				"",
+				"// ===END PRIVATE DOMAINS===",
+			),
+			want: byteLines(
+				"// ===BEGIN ICANN DOMAINS===",
+				"// ===END ICANN DOMAINS===",
+				"// ===BEGIN PRIVATE DOMAINS===",
+				"// A comment right after a marker",
+				"",
+				"// ===END PRIVATE DOMAINS===",
+				"",
+			),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Format(Parse(test.in))
+			checkDiff(t, "Format output", string(got), string(test.want))
+
+			again := Format(Parse(got))
+			checkDiff(t, "reformatted output", string(again), string(got))
+		})
+	}
+}
+
+// TestFormatRealList checks that formatting the real public suffix
+// list reproduces it byte for byte.
+func TestFormatRealList(t *testing.T) {
+	bs, err := os.ReadFile("../../../public_suffix_list.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := Format(Parse(bs)); !bytes.Equal(got, bs) {
+		checkDiff(t, "formatted real list", string(got), string(bs))
+	}
+}
+
+func TestFormatCanonical(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   []byte
+		want []byte
+	}{
+		{
+			name: "extra_blank_lines",
+			in: byteLines(
+				"",
+				"// Top-level comment",
+				"",
+				"",
+				"",
+				"// DuckCorp Inc: https://example.com",
+				"example.com",
+				"",
+				"",
+			),
+			want: byteLines(
+				"// Top-level comment",
+				"",
+				"// DuckCorp Inc: https://example.com",
+				"example.com",
+				"",
+			),
+		},
+		{
+			name: "adjacent_section_markers",
+			in: byteLines(
+				"// ===BEGIN ICANN DOMAINS===",
+				"// ===END ICANN DOMAINS===",
+				"",
+				"",
+				"// ===BEGIN PRIVATE DOMAINS===",
+				"// A comment right after a marker",
+				"",
+				"// ===END PRIVATE DOMAINS===",
+			),
+			want: byteLines(
+				"// ===BEGIN ICANN DOMAINS===",
+				"// ===END ICANN DOMAINS===",
+				"",
+				"// ===BEGIN PRIVATE DOMAINS===",
+				"// A comment right after a marker",
+				"",
+				"// ===END PRIVATE DOMAINS===",
+				"",
+			),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := FormatCanonical(Parse(test.in))
+			checkDiff(t, "FormatCanonical output", string(got), string(test.want))
+
+			again := FormatCanonical(Parse(got))
+			checkDiff(t, "reformatted output", string(again), string(got))
+			checkDiff(t, "Format of canonical output", string(Format(Parse(got))), string(got))
+		})
+	}
+}
+
+// TestFormatCanonicalRealList checks that canonically formatting the
+// real public suffix list only changes blank lines, and that the
+// result is stable.
+func TestFormatCanonicalRealList(t *testing.T) {
+	bs, err := os.ReadFile("../../../public_suffix_list.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := FormatCanonical(Parse(bs))
+
+	nonBlank := func(bs []byte) []string {
+		var ret []string
+		for _, line := range strings.Split(string(bs), "\n") {
+			if line != "" {
+				ret = append(ret, line)
+			}
+		}
+		return ret
+	}
+	checkDiff(t, "formatted non-blank lines", nonBlank(got), nonBlank(bs))
+
+	if again := FormatCanonical(Parse(got)); !bytes.Equal(again, got) {
+		t.Error("canonically formatting the real list is not idempotent")
+	}
+}
+
+func TestSortSuffixes(t *testing.T) {
+	t.Parallel()
+
+	in := byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// Unsorted ICANN suffixes are left alone",
+		"org",
+		"com",
+		"",
+		"// ===END ICANN DOMAINS===",
+		"",
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc: https://example.com",
+		"// Submitted by Not A Duck <duck@example.com>",
+		"!b.example.com",
+		"a.example.com",
+		"*.example.com",
+		"example.com",
+		"// The comment stays between the same lines",
+		"example.org",
+		"example.net",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	)
+	want := byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// Unsorted ICANN suffixes are left alone",
+		"org",
+		"com",
+		"",
+		"// ===END ICANN DOMAINS===",
+		"",
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc: https://example.com",
+		"// Submitted by Not A Duck <duck@example.com>",
+		"example.com",
+		"*.example.com",
+		"a.example.com",
+		"!b.example.com",
+		"// The comment stays between the same lines",
+		"example.net",
+		"example.org",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+		"",
+	)
+
+	f := Parse(in)
+	SortSuffixes(f)
+	got := Format(f)
+	checkDiff(t, "sorted output", string(got), string(want))
+
+	for _, err := range Parse(got).Warnings {
+		if _, ok := err.(UnsortedSuffixes); ok {
+			t.Errorf("sorted output still has unsorted suffixes: %v", err)
+		}
+	}
+}
+
+func TestSortExceptions(t *testing.T) {
+	t.Parallel()
+
+	in := byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// Only exceptions move",
+		"*.kobe.jp",
+		"!ward.kobe.jp",
+		"org",
+		"!city.kobe.jp",
+		"!town.kobe.jp",
+		"*.ck",
+		"!www.ck",
+		"",
+		"*.kawasaki.jp",
+		"!city.kawasaki.jp",
+		"// Comments stay where they are",
+		"!town.kawasaki.jp",
+		"",
+		"// ===END ICANN DOMAINS===",
+	)
+	want := byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// Only exceptions move",
+		"*.kobe.jp",
+		"!city.kobe.jp",
+		"org",
+		"!town.kobe.jp",
+		"!ward.kobe.jp",
+		"*.ck",
+		"!www.ck",
+		"",
+		"*.kawasaki.jp",
+		"!city.kawasaki.jp",
+		"// Comments stay where they are",
+		"!town.kawasaki.jp",
+		"",
+		"// ===END ICANN DOMAINS===",
+		"",
+	)
+
+	f := Parse(in)
+	SortExceptions(f)
+	got := Format(f)
+	checkDiff(t, "sorted output", string(got), string(want))
+
+	for _, err := range Parse(got).Warnings {
+		if _, ok := err.(UnsortedExceptions); ok {
+			t.Errorf("sorted output still has unsorted exceptions: %v", err)
+		}
+	}
+}
+
+func TestNormalizeMetadata(t *testing.T) {
+	t.Parallel()
+
+	in := byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// ICANN headers are left alone",
+		"// https://example.com",
+		"com",
+		"",
+		"// ===END ICANN DOMAINS===",
+		"",
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// Submitted by Not A Duck <duck@example.com>",
+		"// DuckCorp Inc   :   https://example.com",
+		"// Seriously, not a duck",
+		"example.com",
+		"",
+		"// DuckCorp Inc",
+		"// https://example.net",
+		"// Submitted by: Not A Duck <duck@example.net>",
+		"// Also not a duck",
+		"example.net",
+		"",
+		"// DuckCorp Inc (https://example.org)",
+		"// duck@example.org",
+		"example.org",
+		"",
+		"// No URL, left alone",
+		"// Submitted by Not A Duck <duck@example.info>",
+		"example.info",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	)
+	want := byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// ICANN headers are left alone",
+		"// https://example.com",
+		"com",
+		"",
+		"// ===END ICANN DOMAINS===",
+		"",
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc : https://example.com",
+		"// Submitted by Not A Duck <duck@example.com>",
+		"// Seriously, not a duck",
+		"example.com",
+		"",
+		"// DuckCorp Inc : https://example.net",
+		"// Submitted by Not A Duck <duck@example.net>",
+		"// Also not a duck",
+		"example.net",
+		"",
+		"// DuckCorp Inc : https://example.org",
+		"// Submitted by <duck@example.org>",
+		"example.org",
+		"",
+		"// No URL, left alone",
+		"// Submitted by Not A Duck <duck@example.info>",
+		"example.info",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+		"",
+	)
+
+	f := Parse(in)
+	NormalizeMetadata(f)
+	got := Format(f)
+	checkDiff(t, "normalized output", string(got), string(want))
+
+	f = Parse(got)
+	NormalizeMetadata(f)
+	again := Format(f)
+	checkDiff(t, "normalizing twice", string(again), string(got))
+}
+
+func TestNormalizeMetadataRealList(t *testing.T) {
+	bs, err := os.ReadFile("../../../public_suffix_list.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before := Parse(bs)
+	after := Parse(bs)
+	NormalizeMetadata(after)
+	out := Format(after)
+	got := Parse(out)
+
+	checkDiff(t, "metadata after normalizing", metadata(got), metadata(before))
+
+	NormalizeMetadata(got)
+	if again := Format(got); !bytes.Equal(again, out) {
+		t.Error("NormalizeMetadata is not idempotent on the real PSL")
+	}
+}
+
+// metadata returns the entity, URL and submitter of every suffix block
+// in f, one string per block.
+func metadata(f *File) []string {
+	var ret []string
+	for _, block := range f.AllSuffixBlocks() {
+		ret = append(ret, fmt.Sprintf("%q %v %v", block.Entity, block.URL, block.Submitter))
+	}
+	return ret
+}
+
+func TestMergeSuffixes(t *testing.T) {
+	t.Parallel()
+
+	in := byteLines(
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc : https://example.com",
+		"// Submitted by Not A Duck <duck@example.com>",
+		"example.com",
+		"*.example.com",
+		"",
+		"// Goose Gang : https://example.org",
+		"example.org",
+		"",
+		"// DuckCorp Inc : https://example.com",
+		"// Submitted by Not A Duck <duck@example.com>",
+		"// Ducks also own example.net",
+		"Example.com",
+		"example.net",
+		"// Pond suffixes",
+		"*.pond.example.net",
+		"example.net",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	)
+	want := byteLines(
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc : https://example.com",
+		"// Submitted by Not A Duck <duck@example.com>",
+		"// Ducks also own example.net",
+		"example.com",
+		"*.example.com",
+		"example.net",
+		"// Pond suffixes",
+		"*.pond.example.net",
+		"",
+		"// Goose Gang : https://example.org",
+		"example.org",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+		"",
+	)
+
+	f := Parse(in)
+	blocks := f.AllSuffixBlocks()
+	merged, err := MergeSuffixes(blocks[0], blocks[2])
+	if err != nil {
+		t.Fatalf("MergeSuffixes failed: %v", err)
+	}
+	f.Blocks = []Block{f.Blocks[0], merged, f.Blocks[2], f.Blocks[4]}
+	checkDiff(t, "merged output", string(Format(f)), string(want))
+
+	// The merged block's text and location cover all merged lines.
+	wantBlock := Parse(want).AllSuffixBlocks()[0]
+	checkDiff(t, "merged block text", merged.Text(), wantBlock.Text())
+	checkDiff(t, "merged block location", merged.LocationString(), wantBlock.LocationString())
+
+	// Metadata missing from the first block comes from the second.
+	merged, err = MergeSuffixes(Suffixes{Entity: "DuckCorp Inc"}, blocks[2])
+	if err != nil {
+		t.Fatalf("MergeSuffixes failed: %v", err)
+	}
+	if merged.URL.String() != "https://example.com" || merged.Submitter.Address != "duck@example.com" {
+		t.Errorf("merged block has wrong metadata: %v %v", merged.URL, merged.Submitter)
+	}
+
+	// Blocks for different entities cannot be merged.
+	if _, err := MergeSuffixes(blocks[0], blocks[1]); err == nil {
+		t.Errorf("MergeSuffixes of different entities succeeded")
+	}
+	other := blocks[2]
+	other.Submitter = &mail.Address{Address: "goose@example.com"}
+	if _, err := MergeSuffixes(blocks[0], other); err == nil {
+		t.Errorf("MergeSuffixes with different contact emails succeeded")
+	}
+}
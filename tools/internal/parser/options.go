@@ -0,0 +1,91 @@
+package parser
+
+import "regexp"
+
+// Options configures parsing and validation. The zero value selects
+// the default behavior, which is what Parse uses.
+//
+// Options are passed to each parse, rather than set globally, so that
+// concurrent parses with different options don't interfere, and so
+// that results only depend on the inputs.
+type Options struct {
+	// Reference, if not nil, is a trusted version of the PSL whose
+	// ICANN section validations use to look up ICANN suffixes. See
+	// ParseWithReference.
+	Reference *File
+
+	// MaxLineLength is the length in characters beyond which a line
+	// is reported with a LongLineError. Zero means
+	// DefaultMaxLineLength, and a negative value disables the check.
+	MaxLineLength int
+
+	// ContactInfoExemptions are the source text of extra blocks of
+	// suffixes that are exempt from the requirement to provide email
+	// contact information, in addition to the built-in ones (see
+	// ContactInfoExemptions). Like the built-in ones, editing a block
+	// revokes its exemption.
+	ContactInfoExemptions []string
+
+	// MaxMisfiledICANNEntries is the largest number of suffixes an
+	// ICANN block can have to be reported with a
+	// SuffixInWrongSection warning. Larger blocks are assumed to be
+	// registry-managed, like lists of geographic names under a
+	// ccTLD. Zero means DefaultMaxMisfiledICANNEntries, and a
+	// negative value disables the check.
+	MaxMisfiledICANNEntries int
+
+	// GenericEntityNames are the patterns for entity names that are
+	// too vague to identify a maintainer, such as "Test" or "My
+	// Company", which are reported with a GenericEntityName warning.
+	// Use CompileGenericEntityNames to build them. Nil means the
+	// built-in patterns, and an empty non-nil slice disables the
+	// check.
+	GenericEntityNames []*regexp.Regexp
+
+	// DanglingExceptionErrors makes ValidateChanges report exceptions
+	// left behind by removed suffixes (see DanglingException) as
+	// errors rather than warnings.
+	DanglingExceptionErrors bool
+}
+
+// DefaultMaxLineLength is the line length that LongLineError reports
+// lines beyond, unless Options say otherwise.
+const DefaultMaxLineLength = 80
+
+// maxLineLength returns the effective line length limit of o, or zero
+// if long lines aren't reported.
+func (o Options) maxLineLength() int {
+	switch {
+	case o.MaxLineLength == 0:
+		return DefaultMaxLineLength
+	case o.MaxLineLength < 0:
+		return 0
+	}
+	return o.MaxLineLength
+}
+
+// DefaultMaxMisfiledICANNEntries is the largest number of suffixes an
+// ICANN block can have to be reported with a SuffixInWrongSection
+// warning, unless Options say otherwise.
+const DefaultMaxMisfiledICANNEntries = 5
+
+// maxMisfiledICANNEntries returns the effective size limit of o for
+// SuffixInWrongSection, or zero if misfiled blocks aren't reported.
+func (o Options) maxMisfiledICANNEntries() int {
+	switch {
+	case o.MaxMisfiledICANNEntries == 0:
+		return DefaultMaxMisfiledICANNEntries
+	case o.MaxMisfiledICANNEntries < 0:
+		return 0
+	}
+	return o.MaxMisfiledICANNEntries
+}
+
+// genericEntityNames returns the effective generic entity name
+// patterns of o.
+func (o Options) genericEntityNames() []*regexp.Regexp {
+	if o.GenericEntityNames == nil {
+		return defaultGenericEntityNames
+	}
+	return o.GenericEntityNames
+}
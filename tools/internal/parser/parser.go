@@ -2,6 +2,8 @@
 package parser
 
 import (
+	"context"
+	"io"
 	"net/mail"
 	"net/url"
 	"strings"
@@ -20,17 +22,106 @@ import (
 // (https://github.com/publicsuffix/list/wiki/Guidelines). A File with
 // errors should not be used to calculate public suffixes for FQDNs.
 func Parse(bs []byte) *File {
-	return parseWithExceptions(bs, downgradeToWarning)
+	return ParseWithOptions(bs, "", Options{})
 }
 
-func parseWithExceptions(bs []byte, downgradeToWarning func(error) bool) *File {
-	src, errs := newSource(bs)
+// ParseReader reads a PSL file from r and parses it like Parse. path
+// is the name of the file being read, and is recorded in the location
+// information of the parse result and errors. It only returns an error
+// if reading from r fails.
+func ParseReader(r io.Reader, path string) (*File, error) {
+	bs, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return ParseWithOptions(bs, path, Options{}), nil
+}
+
+// ParseStream parses bs, which was read from the file at path, like
+// ParseWithOptions, and sends each error on the returned channel as
+// soon as it is found. Only errors that would be recorded in
+// File.Errors are sent, not warnings. The channel is closed once
+// parsing and validation are complete.
+//
+// The returned result function drains any errors left in the channel,
+// waits for the parse to complete and returns the parse result, which
+// includes every error sent on the channel.
+//
+// Callers that stop reading from the channel early must cancel ctx,
+// or call result, so that the parse can finish. Once ctx is done, no
+// more errors are sent, remaining validations are skipped and the
+// parse result is incomplete.
+func ParseStream(ctx context.Context, bs []byte, path string, opts Options) (errs <-chan error, result func() *File) {
+	ch := make(chan error)
+	p := &parser{
+		downgradeToWarning: downgradeToWarning,
+		opts:               opts,
+		ctx:                ctx,
+		onError: func(err error) {
+			select {
+			case ch <- err:
+			case <-ctx.Done():
+			}
+		},
+	}
+	go func() {
+		defer close(ch)
+		p.run(bs, path)
+	}()
+	result = func() *File {
+		for range ch {
+		}
+		return &p.File
+	}
+	return ch, result
+}
+
+// ParseWithReference parses bs like ParseReader, but validations that
+// look up ICANN suffixes, such as checking that private suffixes are
+// under a known TLD, use the ICANN section of ref instead of the one
+// in bs. ref is usually a trusted older version of the PSL, so that
+// a change can't sneak a private suffix through by also editing the
+// ICANN section. Private suffixes must still not appear in the ICANN
+// section of either file. If ref is nil, ParseWithReference is the
+// same as parsing bs with ParseReader.
+func ParseWithReference(bs []byte, path string, ref *File) *File {
+	return ParseWithOptions(bs, path, Options{Reference: ref})
+}
+
+// ParseWithOptions parses bs, which was read from the file at path,
+// like ParseReader, but configured by opts.
+func ParseWithOptions(bs []byte, path string, opts Options) *File {
+	_, result := ParseStream(context.Background(), bs, path, opts)
+	return result()
+}
+
+// ParseWithSummary parses bs like ParseWithOptions, and also returns
+// a summary of the validations that ran and what each of them found.
+func ParseWithSummary(bs []byte, path string, opts Options) (*File, ValidationSummary) {
 	p := parser{
 		downgradeToWarning: downgradeToWarning,
+		opts:               opts,
 	}
+	f := p.run(bs, path)
+	return f, p.summary
+}
+
+func parseWithExceptions(bs []byte, path string, downgradeToWarning func(error) bool) *File {
+	p := parser{
+		downgradeToWarning: downgradeToWarning,
+	}
+	return p.run(bs, path)
+}
+
+// run parses and validates bs, which was read from path.
+func (p *parser) run(bs []byte, path string) *File {
+	src, errs := newSource(bs, path, p.opts.maxLineLength())
 	for _, err := range errs {
 		p.addError(err)
 	}
+	if err := trailingNewlineError(src); err != nil {
+		p.addError(err)
+	}
 	p.Parse(src)
 	p.Validate()
 	return &p.File
@@ -44,6 +135,11 @@ type parser struct {
 	// parser is not currently within a logical section.
 	currentSection *StartSection
 
+	// sections are the sections that have been started so far, by
+	// name. This is used to report sections that appear more than
+	// once.
+	sections map[string]StartSection
+
 	// downgradeToWarning is a function that reports whether an error
 	// should be recorded as a non-fatal warning. See exceptions.go
 	// for the normal implementation. It's a struct field so that
@@ -51,6 +147,21 @@ type parser struct {
 	// else for testing.
 	downgradeToWarning func(error) bool
 
+	// opts configures the parse. See Options.
+	opts Options
+
+	// onError, if not nil, is called with every error as it is
+	// recorded in File.Errors.
+	onError func(error)
+
+	// ctx, if not nil, stops validation early once it is done. See
+	// ParseStream.
+	ctx context.Context
+
+	// summary records the findings of each validation that ran. See
+	// ParseWithSummary.
+	summary ValidationSummary
+
 	// File is the parser's output.
 	File
 }
@@ -60,7 +171,14 @@ func (p *parser) Parse(src Source) {
 	blankLine := func(line Source) bool { return line.Text() == "" }
 	blocks := src.split(blankLine)
 
+	end := src.lineOffset
 	for _, block := range blocks {
+		if p.blankLines == nil {
+			p.blankLines = map[int]int{}
+		}
+		p.blankLines[block.lineOffset] = block.lineOffset - end
+		end = block.lineOffset + len(block.lines)
+
 		// Does this block have any non-comments in it? If so, it's a
 		// suffix block, otherwise it's a comment/section marker
 		// block.
@@ -128,6 +246,12 @@ func (p *parser) processSuffixes(block, header, rest Source) {
 
 const sectionMarkerPrefix = "// ==="
 
+// knownSections are the names of the sections a PSL file can have.
+var knownSections = map[string]bool{
+	"ICANN DOMAINS":   true,
+	"PRIVATE DOMAINS": true,
+}
+
 // processTopLevelComment parses a block that has only comment lines,
 // no suffixes. Some of those comments may be markers for the
 // start/end of file sections.
@@ -148,6 +272,13 @@ func (p *parser) processTopLevelComment(block Source) {
 
 // processSectionMarker parses line as a file section marker, and
 // enforces correct start/end pairing.
+//
+// Section errors are reported in the order of the marker lines they
+// are about, and for a single marker line always in the same order:
+// structural errors (nesting or pairing) first, then problems with
+// the section name, then a missing "===" trailer. Tools diff error
+// output between runs, so this order must not depend on anything but
+// the input.
 func (p *parser) processSectionMarker(line Source) {
 	// Trim here rather than in the caller, so that we still have the
 	// complete input line available to use in errors.
@@ -187,6 +318,22 @@ func (p *parser) processSectionMarker(line Source) {
 				Inner: start,
 			})
 		}
+		if !knownSections[name] {
+			p.addError(UnknownSectionName{
+				Line: src,
+				Name: name,
+			})
+		} else if prev, ok := p.sections[name]; ok {
+			p.addError(DuplicateSection{
+				Previous: prev,
+				Start:    start,
+			})
+		} else {
+			if p.sections == nil {
+				p.sections = map[string]StartSection{}
+			}
+			p.sections[name] = start
+		}
 		if !hasTrailer {
 			p.addError(UnterminatedSectionMarker{src})
 		}
@@ -203,6 +350,12 @@ func (p *parser) processSectionMarker(line Source) {
 			p.addError(UnstartedSectionError{
 				End: end,
 			})
+			if !knownSections[name] {
+				p.addError(UnknownSectionName{
+					Line: src,
+					Name: name,
+				})
+			}
 		} else if p.currentSection.Name != name {
 			// Mismatched start/end.
 			p.addError(MismatchedSectionError{
@@ -466,12 +619,25 @@ func (p *parser) addBlock(b Block) {
 
 // addError records err as a parse/validation error.
 //
-// If err matches a legacy exemption from current validation rules,
-// err is recorded as a non-fatal warning instead.
+// If err is only a warning (see Severity), or if err matches a legacy
+// exemption from current validation rules, err is recorded as a
+// non-fatal warning instead.
 func (p *parser) addError(err error) {
-	if p.downgradeToWarning(err) {
+	if errorSeverity(err) == SeverityWarning || p.downgradeToWarning(err) || p.exemptByOptions(err) {
 		p.File.Warnings = append(p.File.Warnings, err)
 	} else {
 		p.File.Errors = append(p.File.Errors, err)
+		if p.onError != nil {
+			p.onError(err)
+		}
+	}
+}
+
+// errorSeverity returns the severity of err. Errors that don't
+// declare a severity are treated as SeverityError.
+func errorSeverity(err error) Severity {
+	if e, ok := err.(interface{ Severity() Severity }); ok {
+		return e.Severity()
 	}
+	return SeverityError
 }
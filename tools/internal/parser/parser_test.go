@@ -3,12 +3,16 @@ package parser
 import (
 	"bytes"
 	"cmp"
+	"context"
+	"fmt"
 	"net/mail"
 	"net/url"
 	"os"
+	"regexp"
 	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	diff "github.com/google/go-cmp/cmp"
 )
@@ -113,6 +117,16 @@ func TestParser(t *testing.T) {
 						Name:   "FAKE DOMAINS",
 					},
 				},
+				Errors: []error{
+					UnknownSectionName{
+						Line: mkSrc(0, "// ===BEGIN IMAGINARY DOMAINS==="),
+						Name: "IMAGINARY DOMAINS",
+					},
+					UnknownSectionName{
+						Line: mkSrc(3, "// ===BEGIN FAKE DOMAINS==="),
+						Name: "FAKE DOMAINS",
+					},
+				},
 			},
 		},
 
@@ -177,6 +191,10 @@ func TestParser(t *testing.T) {
 							Name:   "SECRET DOMAINS",
 						},
 					},
+					UnknownSectionName{
+						Line: mkSrc(1, "// ===BEGIN SECRET DOMAINS==="),
+						Name: "SECRET DOMAINS",
+					},
 					UnstartedSectionError{
 						EndSection{
 							Source: mkSrc(3, "// ===END ICANN DOMAINS==="),
@@ -219,6 +237,77 @@ func TestParser(t *testing.T) {
 			},
 		},
 
+		{
+			name: "misspelled_and_duplicate_sections",
+			psl: byteLines(
+				"// ===BEGIN ICANN DOMAINS===",
+				"// ===END ICANN DOMAINS===",
+				"// ===BEGIN ICANN DOMAINS===",
+				"// ===END ICANN DOMAINS===",
+				"// ===BEGIN PRIVATE DOMIANS===",
+				"// ===END PRIVATE DOMIANS===",
+				"// ===END PRIVATE DOMAINZ===",
+			),
+			want: File{
+				Blocks: []Block{
+					StartSection{
+						Source: mkSrc(0, "// ===BEGIN ICANN DOMAINS==="),
+						Name:   "ICANN DOMAINS",
+					},
+					EndSection{
+						Source: mkSrc(1, "// ===END ICANN DOMAINS==="),
+						Name:   "ICANN DOMAINS",
+					},
+					StartSection{
+						Source: mkSrc(2, "// ===BEGIN ICANN DOMAINS==="),
+						Name:   "ICANN DOMAINS",
+					},
+					EndSection{
+						Source: mkSrc(3, "// ===END ICANN DOMAINS==="),
+						Name:   "ICANN DOMAINS",
+					},
+					StartSection{
+						Source: mkSrc(4, "// ===BEGIN PRIVATE DOMIANS==="),
+						Name:   "PRIVATE DOMIANS",
+					},
+					EndSection{
+						Source: mkSrc(5, "// ===END PRIVATE DOMIANS==="),
+						Name:   "PRIVATE DOMIANS",
+					},
+					EndSection{
+						Source: mkSrc(6, "// ===END PRIVATE DOMAINZ==="),
+						Name:   "PRIVATE DOMAINZ",
+					},
+				},
+				Errors: []error{
+					DuplicateSection{
+						Previous: StartSection{
+							Source: mkSrc(0, "// ===BEGIN ICANN DOMAINS==="),
+							Name:   "ICANN DOMAINS",
+						},
+						Start: StartSection{
+							Source: mkSrc(2, "// ===BEGIN ICANN DOMAINS==="),
+							Name:   "ICANN DOMAINS",
+						},
+					},
+					UnknownSectionName{
+						Line: mkSrc(4, "// ===BEGIN PRIVATE DOMIANS==="),
+						Name: "PRIVATE DOMIANS",
+					},
+					UnstartedSectionError{
+						EndSection{
+							Source: mkSrc(6, "// ===END PRIVATE DOMAINZ==="),
+							Name:   "PRIVATE DOMAINZ",
+						},
+					},
+					UnknownSectionName{
+						Line: mkSrc(6, "// ===END PRIVATE DOMAINZ==="),
+						Name: "PRIVATE DOMAINZ",
+					},
+				},
+			},
+		},
+
 		{
 			name: "unknown_section_header",
 			psl: byteLines(
@@ -540,44 +629,50 @@ func TestParser(t *testing.T) {
 		},
 
 		{
-			name: "legacy_error_downgrade",
+			name: "invalid_email_domain",
 			psl: byteLines(
-				"// https://example.com",
+				"// DuckCorp Inc: https://example.com",
+				"// Submitted by Not A Duck <duck@localhost>",
 				"example.com",
 			),
-			downgradeToWarning: func(e error) bool {
-				return true
-			},
 			want: File{
 				Blocks: []Block{
 					Suffixes{
 						Source: mkSrc(0,
-							"// https://example.com",
+							"// DuckCorp Inc: https://example.com",
+							"// Submitted by Not A Duck <duck@localhost>",
 							"example.com",
 						),
 						Header: []Source{
-							mkSrc(0, "// https://example.com"),
+							mkSrc(0, "// DuckCorp Inc: https://example.com"),
+							mkSrc(1, "// Submitted by Not A Duck <duck@localhost>"),
 						},
 						Entries: []Source{
-							mkSrc(1, "example.com"),
+							mkSrc(2, "example.com"),
 						},
-						URL: mustURL("https://example.com"),
+						Entity:    "DuckCorp Inc",
+						URL:       mustURL("https://example.com"),
+						Submitter: mustEmail("Not A Duck <duck@localhost>"),
 					},
 				},
-				Warnings: []error{
-					MissingEntityName{
+				Errors: []error{
+					InvalidEntityEmail{
 						Suffixes: Suffixes{
 							Source: mkSrc(0,
-								"// https://example.com",
+								"// DuckCorp Inc: https://example.com",
+								"// Submitted by Not A Duck <duck@localhost>",
 								"example.com",
 							),
 							Header: []Source{
-								mkSrc(0, "// https://example.com"),
+								mkSrc(0, "// DuckCorp Inc: https://example.com"),
+								mkSrc(1, "// Submitted by Not A Duck <duck@localhost>"),
 							},
 							Entries: []Source{
-								mkSrc(1, "example.com"),
+								mkSrc(2, "example.com"),
 							},
-							URL: mustURL("https://example.com"),
+							Entity:    "DuckCorp Inc",
+							URL:       mustURL("https://example.com"),
+							Submitter: mustEmail("Not A Duck <duck@localhost>"),
 						},
 					},
 				},
@@ -585,305 +680,3230 @@ func TestParser(t *testing.T) {
 		},
 
 		{
-			// Regression test for Future Versatile Group, who use a
-			// unicode fullwidth colon in their header.
-			name: "unicode_colon",
+			name: "invalid_urls",
 			psl: byteLines(
-				"// Future Versatile Group：https://example.org",
+				"// DuckCorp Inc: htp://example.com",
 				"example.com",
+				"",
+				"// Duck Hut: http://example.org",
+				"example.org",
 			),
 			want: File{
 				Blocks: []Block{
 					Suffixes{
-						Source: mkSrc(0, "// Future Versatile Group：https://example.org", "example.com"),
+						Source: mkSrc(0, "// DuckCorp Inc: htp://example.com", "example.com"),
 						Header: []Source{
-							mkSrc(0, "// Future Versatile Group：https://example.org"),
+							mkSrc(0, "// DuckCorp Inc: htp://example.com"),
 						},
 						Entries: []Source{
 							mkSrc(1, "example.com"),
 						},
-						Entity: "Future Versatile Group",
-						URL:    mustURL("https://example.org"),
+						Entity: "DuckCorp Inc: htp://example.com",
+					},
+					Suffixes{
+						Source: mkSrc(3, "// Duck Hut: http://example.org", "example.org"),
+						Header: []Source{
+							mkSrc(3, "// Duck Hut: http://example.org"),
+						},
+						Entries: []Source{
+							mkSrc(4, "example.org"),
+						},
+						Entity: "Duck Hut",
+						URL:    mustURL("http://example.org"),
+					},
+				},
+				Errors: []error{
+					InvalidEntityURL{
+						Suffixes: Suffixes{
+							Source: mkSrc(0, "// DuckCorp Inc: htp://example.com", "example.com"),
+							Header: []Source{
+								mkSrc(0, "// DuckCorp Inc: htp://example.com"),
+							},
+							Entries: []Source{
+								mkSrc(1, "example.com"),
+							},
+							Entity: "DuckCorp Inc: htp://example.com",
+						},
+						URL: "htp://example.com",
+					},
+				},
+				Warnings: []error{
+					InsecureEntityURL{
+						Suffixes: Suffixes{
+							Source: mkSrc(3, "// Duck Hut: http://example.org", "example.org"),
+							Header: []Source{
+								mkSrc(3, "// Duck Hut: http://example.org"),
+							},
+							Entries: []Source{
+								mkSrc(4, "example.org"),
+							},
+							Entity: "Duck Hut",
+							URL:    mustURL("http://example.org"),
+						},
 					},
 				},
 			},
 		},
 
 		{
-			// Regression test for a few blocks that start with "name
-			// (url)" instead of the more common "name: url".
-			name: "url_in_parens",
+			name: "redundant_private_suffix",
 			psl: byteLines(
-				"// Parens Appreciation Society (https://example.org)",
+				"// ===BEGIN PRIVATE DOMAINS===",
+				"",
+				"// DuckCorp Inc: https://example.com",
+				"// Submitted by Not A Duck <duck@example.com>",
 				"example.com",
+				"*.example.com",
+				"pond.example.com",
+				"!www.example.com",
+				"",
+				"// ===END PRIVATE DOMAINS===",
 			),
 			want: File{
 				Blocks: []Block{
+					StartSection{
+						Source: mkSrc(0, "// ===BEGIN PRIVATE DOMAINS==="),
+						Name:   "PRIVATE DOMAINS",
+					},
 					Suffixes{
-						Source: mkSrc(0, "// Parens Appreciation Society (https://example.org)", "example.com"),
+						Source: mkSrc(2,
+							"// DuckCorp Inc: https://example.com",
+							"// Submitted by Not A Duck <duck@example.com>",
+							"example.com",
+							"*.example.com",
+							"pond.example.com",
+							"!www.example.com",
+						),
 						Header: []Source{
-							mkSrc(0, "// Parens Appreciation Society (https://example.org)"),
+							mkSrc(2, "// DuckCorp Inc: https://example.com"),
+							mkSrc(3, "// Submitted by Not A Duck <duck@example.com>"),
 						},
 						Entries: []Source{
-							mkSrc(1, "example.com"),
+							mkSrc(4, "example.com"),
+							mkSrc(5, "*.example.com"),
+							mkSrc(6, "pond.example.com"),
+							mkSrc(7, "!www.example.com"),
 						},
-						Entity: "Parens Appreciation Society",
-						URL:    mustURL("https://example.org"),
+						Entity:    "DuckCorp Inc",
+						URL:       mustURL("https://example.com"),
+						Submitter: mustEmail("Not A Duck <duck@example.com>"),
+					},
+					EndSection{
+						Source: mkSrc(9, "// ===END PRIVATE DOMAINS==="),
+						Name:   "PRIVATE DOMAINS",
+					},
+				},
+				Errors: []error{
+					SuffixWildcardOverlap{
+						Suffix:   mkSrc(4, "example.com"),
+						Wildcard: mkSrc(5, "*.example.com"),
+					},
+					SuffixCoveredByWildcard{
+						Suffixes: Suffixes{
+							Source: mkSrc(2,
+								"// DuckCorp Inc: https://example.com",
+								"// Submitted by Not A Duck <duck@example.com>",
+								"example.com",
+								"*.example.com",
+								"pond.example.com",
+								"!www.example.com",
+							),
+							Header: []Source{
+								mkSrc(2, "// DuckCorp Inc: https://example.com"),
+								mkSrc(3, "// Submitted by Not A Duck <duck@example.com>"),
+							},
+							Entries: []Source{
+								mkSrc(4, "example.com"),
+								mkSrc(5, "*.example.com"),
+								mkSrc(6, "pond.example.com"),
+								mkSrc(7, "!www.example.com"),
+							},
+							Entity:    "DuckCorp Inc",
+							URL:       mustURL("https://example.com"),
+							Submitter: mustEmail("Not A Duck <duck@example.com>"),
+						},
+						Suffix:   mkSrc(6, "pond.example.com"),
+						Wildcard: mkSrc(5, "*.example.com"),
+					},
+				},
+				Warnings: []error{
+					RedundantSuffix{
+						Suffixes: Suffixes{
+							Source: mkSrc(2,
+								"// DuckCorp Inc: https://example.com",
+								"// Submitted by Not A Duck <duck@example.com>",
+								"example.com",
+								"*.example.com",
+								"pond.example.com",
+								"!www.example.com",
+							),
+							Header: []Source{
+								mkSrc(2, "// DuckCorp Inc: https://example.com"),
+								mkSrc(3, "// Submitted by Not A Duck <duck@example.com>"),
+							},
+							Entries: []Source{
+								mkSrc(4, "example.com"),
+								mkSrc(5, "*.example.com"),
+								mkSrc(6, "pond.example.com"),
+								mkSrc(7, "!www.example.com"),
+							},
+							Entity:    "DuckCorp Inc",
+							URL:       mustURL("https://example.com"),
+							Submitter: mustEmail("Not A Duck <duck@example.com>"),
+						},
+						Suffix: mkSrc(6, "pond.example.com"),
+						Parent: mkSrc(4, "example.com"),
 					},
 				},
 			},
 		},
 
 		{
-			// Variation on the previous, some blocks in the "name
-			// (url)" style don't have a scheme on their URL, so
-			// require a bit more fudging to parse.
-			name: "url_in_parens_no_scheme",
+			name: "non_canonical_suffixes",
 			psl: byteLines(
-				"// Parens Appreciation Society (hostyhosting.com)",
-				"example.com",
-				"",
-				"// Parens Policy Panel (www.task.gda.pl/uslugi/dns)",
-				"policy.example.org",
+				"// DuckCorp Inc: https://example.com",
+				"Example.COM",
+				"*.example.org",
+				"xn--55qx5d.cn",
 			),
 			want: File{
 				Blocks: []Block{
 					Suffixes{
-						Source: mkSrc(0, "// Parens Appreciation Society (hostyhosting.com)", "example.com"),
+						Source: mkSrc(0,
+							"// DuckCorp Inc: https://example.com",
+							"Example.COM",
+							"*.example.org",
+							"xn--55qx5d.cn",
+						),
 						Header: []Source{
-							mkSrc(0, "// Parens Appreciation Society (hostyhosting.com)"),
+							mkSrc(0, "// DuckCorp Inc: https://example.com"),
 						},
 						Entries: []Source{
-							mkSrc(1, "example.com"),
+							mkSrc(1, "Example.COM"),
+							mkSrc(2, "*.example.org"),
+							mkSrc(3, "xn--55qx5d.cn"),
 						},
-						Entity: "Parens Appreciation Society",
-						URL:    mustURL("https://hostyhosting.com"),
+						Entity: "DuckCorp Inc",
+						URL:    mustURL("https://example.com"),
 					},
-					Suffixes{
-						Source: mkSrc(3, "// Parens Policy Panel (www.task.gda.pl/uslugi/dns)", "policy.example.org"),
-						Header: []Source{
-							mkSrc(3, "// Parens Policy Panel (www.task.gda.pl/uslugi/dns)"),
+				},
+				Errors: []error{
+					NonCanonicalSuffix{
+						Suffixes: Suffixes{
+							Source: mkSrc(0,
+								"// DuckCorp Inc: https://example.com",
+								"Example.COM",
+								"*.example.org",
+								"xn--55qx5d.cn",
+							),
+							Header: []Source{
+								mkSrc(0, "// DuckCorp Inc: https://example.com"),
+							},
+							Entries: []Source{
+								mkSrc(1, "Example.COM"),
+								mkSrc(2, "*.example.org"),
+								mkSrc(3, "xn--55qx5d.cn"),
+							},
+							Entity: "DuckCorp Inc",
+							URL:    mustURL("https://example.com"),
 						},
-						Entries: []Source{
-							mkSrc(4, "policy.example.org"),
+						Suffix: mkSrc(1, "Example.COM"),
+						Want:   "example.com",
+					},
+					NonCanonicalSuffix{
+						Suffixes: Suffixes{
+							Source: mkSrc(0,
+								"// DuckCorp Inc: https://example.com",
+								"Example.COM",
+								"*.example.org",
+								"xn--55qx5d.cn",
+							),
+							Header: []Source{
+								mkSrc(0, "// DuckCorp Inc: https://example.com"),
+							},
+							Entries: []Source{
+								mkSrc(1, "Example.COM"),
+								mkSrc(2, "*.example.org"),
+								mkSrc(3, "xn--55qx5d.cn"),
+							},
+							Entity: "DuckCorp Inc",
+							URL:    mustURL("https://example.com"),
 						},
-						Entity: "Parens Policy Panel",
-						URL:    mustURL("https://www.task.gda.pl/uslugi/dns"),
+						Suffix: mkSrc(3, "xn--55qx5d.cn"),
+						Want:   "公司.cn",
 					},
 				},
 			},
 		},
 
 		{
-			// Regression test for a sneaky bug during development:
-			// when an entity name is found when parsing Suffixes
-			// headers, don't keep trying to find it in subsequent
-			// lines, or you might overwrite the correct answer with
-			// someething else that happens to have the right shape.
-			name: "accept_first_valid_entity",
+			name: "unsorted_private_suffixes",
 			psl: byteLines(
-				"// cd : https://en.wikipedia.org/wiki/.cd",
-				"// see also: https://www.nic.cd/domain/insertDomain_2.jsp?act=1",
-				"cd",
+				"// ===BEGIN PRIVATE DOMAINS===",
+				"",
+				"// DuckCorp Inc: https://example.com",
+				"// Submitted by Not A Duck <duck@example.com>",
+				"example.org",
+				"example.com",
+				"",
+				"// ===END PRIVATE DOMAINS===",
 			),
 			want: File{
 				Blocks: []Block{
+					StartSection{
+						Source: mkSrc(0, "// ===BEGIN PRIVATE DOMAINS==="),
+						Name:   "PRIVATE DOMAINS",
+					},
 					Suffixes{
-						Source: mkSrc(0,
-							"// cd : https://en.wikipedia.org/wiki/.cd",
-							"// see also: https://www.nic.cd/domain/insertDomain_2.jsp?act=1",
-							"cd",
+						Source: mkSrc(2,
+							"// DuckCorp Inc: https://example.com",
+							"// Submitted by Not A Duck <duck@example.com>",
+							"example.org",
+							"example.com",
 						),
 						Header: []Source{
-							mkSrc(0, "// cd : https://en.wikipedia.org/wiki/.cd"),
-							mkSrc(1, "// see also: https://www.nic.cd/domain/insertDomain_2.jsp?act=1"),
+							mkSrc(2, "// DuckCorp Inc: https://example.com"),
+							mkSrc(3, "// Submitted by Not A Duck <duck@example.com>"),
 						},
 						Entries: []Source{
-							mkSrc(2, "cd"),
+							mkSrc(4, "example.org"),
+							mkSrc(5, "example.com"),
 						},
-						Entity: "cd",
-						URL:    mustURL("https://en.wikipedia.org/wiki/.cd"),
+						Entity:    "DuckCorp Inc",
+						URL:       mustURL("https://example.com"),
+						Submitter: mustEmail("Not A Duck <duck@example.com>"),
+					},
+					EndSection{
+						Source: mkSrc(7, "// ===END PRIVATE DOMAINS==="),
+						Name:   "PRIVATE DOMAINS",
 					},
 				},
-			},
-		},
-	}
-
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			exc := test.downgradeToWarning
-			if exc == nil {
+				Warnings: []error{
+					UnsortedSuffixes{
+						Suffixes: Suffixes{
+							Source: mkSrc(2,
+								"// DuckCorp Inc: https://example.com",
+								"// Submitted by Not A Duck <duck@example.com>",
+								"example.org",
+								"example.com",
+							),
+							Header: []Source{
+								mkSrc(2, "// DuckCorp Inc: https://example.com"),
+								mkSrc(3, "// Submitted by Not A Duck <duck@example.com>"),
+							},
+							Entries: []Source{
+								mkSrc(4, "example.org"),
+								mkSrc(5, "example.com"),
+							},
+							Entity:    "DuckCorp Inc",
+							URL:       mustURL("https://example.com"),
+							Submitter: mustEmail("Not A Duck <duck@example.com>"),
+						},
+						Suffix: mkSrc(5, "example.com"),
+						Before: mkSrc(4, "example.org"),
+					},
+				},
+			},
+		},
+
+		{
+			name: "unsorted_suffix_appended",
+			psl: byteLines(
+				"// ===BEGIN PRIVATE DOMAINS===",
+				"",
+				"// DuckCorp Inc: https://example.com",
+				"// Submitted by Not A Duck <duck@example.com>",
+				"a.example.com",
+				"c.example.com",
+				"d.example.com",
+				"b.example.com",
+				"",
+				"// ===END PRIVATE DOMAINS===",
+			),
+			want: File{
+				Blocks: []Block{
+					StartSection{
+						Source: mkSrc(0, "// ===BEGIN PRIVATE DOMAINS==="),
+						Name:   "PRIVATE DOMAINS",
+					},
+					Suffixes{
+						Source: mkSrc(2,
+							"// DuckCorp Inc: https://example.com",
+							"// Submitted by Not A Duck <duck@example.com>",
+							"a.example.com",
+							"c.example.com",
+							"d.example.com",
+							"b.example.com",
+						),
+						Header: []Source{
+							mkSrc(2, "// DuckCorp Inc: https://example.com"),
+							mkSrc(3, "// Submitted by Not A Duck <duck@example.com>"),
+						},
+						Entries: []Source{
+							mkSrc(4, "a.example.com"),
+							mkSrc(5, "c.example.com"),
+							mkSrc(6, "d.example.com"),
+							mkSrc(7, "b.example.com"),
+						},
+						Entity:    "DuckCorp Inc",
+						URL:       mustURL("https://example.com"),
+						Submitter: mustEmail("Not A Duck <duck@example.com>"),
+					},
+					EndSection{
+						Source: mkSrc(9, "// ===END PRIVATE DOMAINS==="),
+						Name:   "PRIVATE DOMAINS",
+					},
+				},
+				Warnings: []error{
+					UnsortedSuffixes{
+						Suffixes: Suffixes{
+							Source: mkSrc(2,
+								"// DuckCorp Inc: https://example.com",
+								"// Submitted by Not A Duck <duck@example.com>",
+								"a.example.com",
+								"c.example.com",
+								"d.example.com",
+								"b.example.com",
+							),
+							Header: []Source{
+								mkSrc(2, "// DuckCorp Inc: https://example.com"),
+								mkSrc(3, "// Submitted by Not A Duck <duck@example.com>"),
+							},
+							Entries: []Source{
+								mkSrc(4, "a.example.com"),
+								mkSrc(5, "c.example.com"),
+								mkSrc(6, "d.example.com"),
+								mkSrc(7, "b.example.com"),
+							},
+							Entity:    "DuckCorp Inc",
+							URL:       mustURL("https://example.com"),
+							Submitter: mustEmail("Not A Duck <duck@example.com>"),
+						},
+						Suffix: mkSrc(7, "b.example.com"),
+						Before: mkSrc(5, "c.example.com"),
+					},
+				},
+			},
+		},
+
+		{
+			name: "unknown_tld",
+			psl: byteLines(
+				"// ===BEGIN ICANN DOMAINS===",
+				"",
+				"// com : https://en.wikipedia.org/wiki/.com",
+				"com",
+				"",
+				"// ck : https://en.wikipedia.org/wiki/.ck",
+				"*.ck",
+				"",
+				"// ===END ICANN DOMAINS===",
+				"// ===BEGIN PRIVATE DOMAINS===",
+				"",
+				"// DuckCorp Inc: https://example.com",
+				"// Submitted by Not A Duck <duck@example.com>",
+				"example.ck",
+				"example.com",
+				"example.comm",
+				"",
+				"// ===END PRIVATE DOMAINS===",
+			),
+			want: File{
+				Blocks: []Block{
+					StartSection{
+						Source: mkSrc(0, "// ===BEGIN ICANN DOMAINS==="),
+						Name:   "ICANN DOMAINS",
+					},
+					Suffixes{
+						Source: mkSrc(2, "// com : https://en.wikipedia.org/wiki/.com", "com"),
+						Header: []Source{
+							mkSrc(2, "// com : https://en.wikipedia.org/wiki/.com"),
+						},
+						Entries: []Source{
+							mkSrc(3, "com"),
+						},
+						Entity: "com",
+						URL:    mustURL("https://en.wikipedia.org/wiki/.com"),
+					},
+					Suffixes{
+						Source: mkSrc(5, "// ck : https://en.wikipedia.org/wiki/.ck", "*.ck"),
+						Header: []Source{
+							mkSrc(5, "// ck : https://en.wikipedia.org/wiki/.ck"),
+						},
+						Entries: []Source{
+							mkSrc(6, "*.ck"),
+						},
+						Entity: "ck",
+						URL:    mustURL("https://en.wikipedia.org/wiki/.ck"),
+					},
+					EndSection{
+						Source: mkSrc(8, "// ===END ICANN DOMAINS==="),
+						Name:   "ICANN DOMAINS",
+					},
+					StartSection{
+						Source: mkSrc(9, "// ===BEGIN PRIVATE DOMAINS==="),
+						Name:   "PRIVATE DOMAINS",
+					},
+					Suffixes{
+						Source: mkSrc(11,
+							"// DuckCorp Inc: https://example.com",
+							"// Submitted by Not A Duck <duck@example.com>",
+							"example.ck",
+							"example.com",
+							"example.comm",
+						),
+						Header: []Source{
+							mkSrc(11, "// DuckCorp Inc: https://example.com"),
+							mkSrc(12, "// Submitted by Not A Duck <duck@example.com>"),
+						},
+						Entries: []Source{
+							mkSrc(13, "example.ck"),
+							mkSrc(14, "example.com"),
+							mkSrc(15, "example.comm"),
+						},
+						Entity:    "DuckCorp Inc",
+						URL:       mustURL("https://example.com"),
+						Submitter: mustEmail("Not A Duck <duck@example.com>"),
+					},
+					EndSection{
+						Source: mkSrc(17, "// ===END PRIVATE DOMAINS==="),
+						Name:   "PRIVATE DOMAINS",
+					},
+				},
+				Errors: []error{
+					UnknownTLD{
+						Suffixes: Suffixes{
+							Source: mkSrc(11,
+								"// DuckCorp Inc: https://example.com",
+								"// Submitted by Not A Duck <duck@example.com>",
+								"example.ck",
+								"example.com",
+								"example.comm",
+							),
+							Header: []Source{
+								mkSrc(11, "// DuckCorp Inc: https://example.com"),
+								mkSrc(12, "// Submitted by Not A Duck <duck@example.com>"),
+							},
+							Entries: []Source{
+								mkSrc(13, "example.ck"),
+								mkSrc(14, "example.com"),
+								mkSrc(15, "example.comm"),
+							},
+							Entity:    "DuckCorp Inc",
+							URL:       mustURL("https://example.com"),
+							Submitter: mustEmail("Not A Duck <duck@example.com>"),
+						},
+						Suffix: mkSrc(15, "example.comm"),
+					},
+				},
+			},
+		},
+
+		{
+			name: "cross_section_duplicate",
+			psl: byteLines(
+				"// ===BEGIN ICANN DOMAINS===",
+				"",
+				"// com : https://en.wikipedia.org/wiki/.com",
+				"com",
+				"example.com",
+				"",
+				"// ===END ICANN DOMAINS===",
+				"// ===BEGIN PRIVATE DOMAINS===",
+				"",
+				"// DuckCorp Inc: https://example.com",
+				"// Submitted by Not A Duck <duck@example.com>",
+				"example.com",
+				"",
+				"// ===END PRIVATE DOMAINS===",
+			),
+			want: File{
+				Blocks: []Block{
+					StartSection{
+						Source: mkSrc(0, "// ===BEGIN ICANN DOMAINS==="),
+						Name:   "ICANN DOMAINS",
+					},
+					Suffixes{
+						Source: mkSrc(2,
+							"// com : https://en.wikipedia.org/wiki/.com",
+							"com",
+							"example.com",
+						),
+						Header: []Source{
+							mkSrc(2, "// com : https://en.wikipedia.org/wiki/.com"),
+						},
+						Entries: []Source{
+							mkSrc(3, "com"),
+							mkSrc(4, "example.com"),
+						},
+						Entity: "com",
+						URL:    mustURL("https://en.wikipedia.org/wiki/.com"),
+					},
+					EndSection{
+						Source: mkSrc(6, "// ===END ICANN DOMAINS==="),
+						Name:   "ICANN DOMAINS",
+					},
+					StartSection{
+						Source: mkSrc(7, "// ===BEGIN PRIVATE DOMAINS==="),
+						Name:   "PRIVATE DOMAINS",
+					},
+					Suffixes{
+						Source: mkSrc(9,
+							"// DuckCorp Inc: https://example.com",
+							"// Submitted by Not A Duck <duck@example.com>",
+							"example.com",
+						),
+						Header: []Source{
+							mkSrc(9, "// DuckCorp Inc: https://example.com"),
+							mkSrc(10, "// Submitted by Not A Duck <duck@example.com>"),
+						},
+						Entries: []Source{
+							mkSrc(11, "example.com"),
+						},
+						Entity:    "DuckCorp Inc",
+						URL:       mustURL("https://example.com"),
+						Submitter: mustEmail("Not A Duck <duck@example.com>"),
+					},
+					EndSection{
+						Source: mkSrc(13, "// ===END PRIVATE DOMAINS==="),
+						Name:   "PRIVATE DOMAINS",
+					},
+				},
+				Errors: []error{
+					CrossSectionDuplicate{
+						ICANN:   mkSrc(4, "example.com"),
+						Private: mkSrc(11, "example.com"),
+					},
+				},
+			},
+		},
+
+		{
+			name: "duplicate_entity_name",
+			psl: byteLines(
+				"// ===BEGIN PRIVATE DOMAINS===",
+				"",
+				"// DuckCorp Inc: https://example.com",
+				"// Submitted by Not A Duck <duck@example.com>",
+				"example.com",
+				"",
+				"// duckcorp  inc : https://example.org",
+				"// Submitted by Not A Duck <duck@example.org>",
+				"example.org",
+				"",
+				"// ===END PRIVATE DOMAINS===",
+			),
+			want: File{
+				Blocks: []Block{
+					StartSection{
+						Source: mkSrc(0, "// ===BEGIN PRIVATE DOMAINS==="),
+						Name:   "PRIVATE DOMAINS",
+					},
+					Suffixes{
+						Source: mkSrc(2,
+							"// DuckCorp Inc: https://example.com",
+							"// Submitted by Not A Duck <duck@example.com>",
+							"example.com",
+						),
+						Header: []Source{
+							mkSrc(2, "// DuckCorp Inc: https://example.com"),
+							mkSrc(3, "// Submitted by Not A Duck <duck@example.com>"),
+						},
+						Entries: []Source{
+							mkSrc(4, "example.com"),
+						},
+						Entity:    "DuckCorp Inc",
+						URL:       mustURL("https://example.com"),
+						Submitter: mustEmail("Not A Duck <duck@example.com>"),
+					},
+					Suffixes{
+						Source: mkSrc(6,
+							"// duckcorp  inc : https://example.org",
+							"// Submitted by Not A Duck <duck@example.org>",
+							"example.org",
+						),
+						Header: []Source{
+							mkSrc(6, "// duckcorp  inc : https://example.org"),
+							mkSrc(7, "// Submitted by Not A Duck <duck@example.org>"),
+						},
+						Entries: []Source{
+							mkSrc(8, "example.org"),
+						},
+						Entity:    "duckcorp  inc",
+						URL:       mustURL("https://example.org"),
+						Submitter: mustEmail("Not A Duck <duck@example.org>"),
+					},
+					EndSection{
+						Source: mkSrc(10, "// ===END PRIVATE DOMAINS==="),
+						Name:   "PRIVATE DOMAINS",
+					},
+				},
+				Errors: []error{
+					DuplicateEntityName{
+						Suffixes: Suffixes{
+							Source: mkSrc(6,
+								"// duckcorp  inc : https://example.org",
+								"// Submitted by Not A Duck <duck@example.org>",
+								"example.org",
+							),
+							Header: []Source{
+								mkSrc(6, "// duckcorp  inc : https://example.org"),
+								mkSrc(7, "// Submitted by Not A Duck <duck@example.org>"),
+							},
+							Entries: []Source{
+								mkSrc(8, "example.org"),
+							},
+							Entity:    "duckcorp  inc",
+							URL:       mustURL("https://example.org"),
+							Submitter: mustEmail("Not A Duck <duck@example.org>"),
+						},
+						Previous: Suffixes{
+							Source: mkSrc(2,
+								"// DuckCorp Inc: https://example.com",
+								"// Submitted by Not A Duck <duck@example.com>",
+								"example.com",
+							),
+							Header: []Source{
+								mkSrc(2, "// DuckCorp Inc: https://example.com"),
+								mkSrc(3, "// Submitted by Not A Duck <duck@example.com>"),
+							},
+							Entries: []Source{
+								mkSrc(4, "example.com"),
+							},
+							Entity:    "DuckCorp Inc",
+							URL:       mustURL("https://example.com"),
+							Submitter: mustEmail("Not A Duck <duck@example.com>"),
+						},
+					},
+				},
+			},
+		},
+
+		{
+			name: "unrelated_maintainer_email",
+			psl: byteLines(
+				"// ===BEGIN PRIVATE DOMAINS===",
+				"",
+				"// Example Inc: https://example.com",
+				"// Submitted by Example Admin <admin@mail.example.com>",
+				"users.example.com",
+				"",
+				"// Example Pages: https://example.net",
+				"// Submitted by Example Admin <admin@example.net>",
+				"example.org",
+				"",
+				"// Other Corp: https://other.com",
+				"// Submitted by Example Admin <admin@unrelated.com>",
+				"other.com",
+				"",
+				"// Free Corp: https://free.com",
+				"// Submitted by Example Admin <admin@gmail.com>",
+				"free.com",
+				"",
+				"// ===END PRIVATE DOMAINS===",
+			),
+			want: File{
+				Blocks: []Block{
+					StartSection{
+						Source: mkSrc(0, "// ===BEGIN PRIVATE DOMAINS==="),
+						Name:   "PRIVATE DOMAINS",
+					},
+					Suffixes{
+						Source: mkSrc(2,
+							"// Example Inc: https://example.com",
+							"// Submitted by Example Admin <admin@mail.example.com>",
+							"users.example.com",
+						),
+						Header: []Source{
+							mkSrc(2, "// Example Inc: https://example.com"),
+							mkSrc(3, "// Submitted by Example Admin <admin@mail.example.com>"),
+						},
+						Entries: []Source{
+							mkSrc(4, "users.example.com"),
+						},
+						Entity:    "Example Inc",
+						URL:       mustURL("https://example.com"),
+						Submitter: mustEmail("Example Admin <admin@mail.example.com>"),
+					},
+					Suffixes{
+						Source: mkSrc(6,
+							"// Example Pages: https://example.net",
+							"// Submitted by Example Admin <admin@example.net>",
+							"example.org",
+						),
+						Header: []Source{
+							mkSrc(6, "// Example Pages: https://example.net"),
+							mkSrc(7, "// Submitted by Example Admin <admin@example.net>"),
+						},
+						Entries: []Source{
+							mkSrc(8, "example.org"),
+						},
+						Entity:    "Example Pages",
+						URL:       mustURL("https://example.net"),
+						Submitter: mustEmail("Example Admin <admin@example.net>"),
+					},
+					Suffixes{
+						Source: mkSrc(10,
+							"// Other Corp: https://other.com",
+							"// Submitted by Example Admin <admin@unrelated.com>",
+							"other.com",
+						),
+						Header: []Source{
+							mkSrc(10, "// Other Corp: https://other.com"),
+							mkSrc(11, "// Submitted by Example Admin <admin@unrelated.com>"),
+						},
+						Entries: []Source{
+							mkSrc(12, "other.com"),
+						},
+						Entity:    "Other Corp",
+						URL:       mustURL("https://other.com"),
+						Submitter: mustEmail("Example Admin <admin@unrelated.com>"),
+					},
+					Suffixes{
+						Source: mkSrc(14,
+							"// Free Corp: https://free.com",
+							"// Submitted by Example Admin <admin@gmail.com>",
+							"free.com",
+						),
+						Header: []Source{
+							mkSrc(14, "// Free Corp: https://free.com"),
+							mkSrc(15, "// Submitted by Example Admin <admin@gmail.com>"),
+						},
+						Entries: []Source{
+							mkSrc(16, "free.com"),
+						},
+						Entity:    "Free Corp",
+						URL:       mustURL("https://free.com"),
+						Submitter: mustEmail("Example Admin <admin@gmail.com>"),
+					},
+					EndSection{
+						Source: mkSrc(18, "// ===END PRIVATE DOMAINS==="),
+						Name:   "PRIVATE DOMAINS",
+					},
+				},
+				Warnings: []error{
+					UnrelatedMaintainerEmail{
+						Suffixes: Suffixes{
+							Source: mkSrc(10,
+								"// Other Corp: https://other.com",
+								"// Submitted by Example Admin <admin@unrelated.com>",
+								"other.com",
+							),
+							Header: []Source{
+								mkSrc(10, "// Other Corp: https://other.com"),
+								mkSrc(11, "// Submitted by Example Admin <admin@unrelated.com>"),
+							},
+							Entries: []Source{
+								mkSrc(12, "other.com"),
+							},
+							Entity:    "Other Corp",
+							URL:       mustURL("https://other.com"),
+							Submitter: mustEmail("Example Admin <admin@unrelated.com>"),
+						},
+					},
+				},
+			},
+		},
+
+		{
+			name: "invalid_wildcard_exceptions",
+			psl: byteLines(
+				"// ===BEGIN ICANN DOMAINS===",
+				"",
+				"// example : https://example.com",
+				"*.com",
+				"!good.com",
+				"!com",
+				"!bad.label.com",
+				"!.com",
+				"*.closer.com",
+				"!good.closer.com",
+				"",
+				"// ===END ICANN DOMAINS===",
+			),
+			want: File{
+				Blocks: []Block{
+					StartSection{
+						Source: mkSrc(0, "// ===BEGIN ICANN DOMAINS==="),
+						Name:   "ICANN DOMAINS",
+					},
+					Suffixes{
+						Source: mkSrc(2,
+							"// example : https://example.com",
+							"*.com",
+							"!good.com",
+							"!com",
+							"!bad.label.com",
+							"!.com",
+							"*.closer.com",
+							"!good.closer.com",
+						),
+						Header: []Source{
+							mkSrc(2, "// example : https://example.com"),
+						},
+						Entries: []Source{
+							mkSrc(3, "*.com"),
+							mkSrc(4, "!good.com"),
+							mkSrc(5, "!com"),
+							mkSrc(6, "!bad.label.com"),
+							mkSrc(7, "!.com"),
+							mkSrc(8, "*.closer.com"),
+							mkSrc(9, "!good.closer.com"),
+						},
+						Entity: "example",
+						URL:    mustURL("https://example.com"),
+					},
+					EndSection{
+						Source: mkSrc(11, "// ===END ICANN DOMAINS==="),
+						Name:   "ICANN DOMAINS",
+					},
+				},
+				Errors: []error{
+					InvalidWildcardException{
+						Suffixes: Suffixes{
+							Source: mkSrc(2,
+								"// example : https://example.com",
+								"*.com",
+								"!good.com",
+								"!com",
+								"!bad.label.com",
+								"!.com",
+								"*.closer.com",
+								"!good.closer.com",
+							),
+							Header: []Source{
+								mkSrc(2, "// example : https://example.com"),
+							},
+							Entries: []Source{
+								mkSrc(3, "*.com"),
+								mkSrc(4, "!good.com"),
+								mkSrc(5, "!com"),
+								mkSrc(6, "!bad.label.com"),
+								mkSrc(7, "!.com"),
+								mkSrc(8, "*.closer.com"),
+								mkSrc(9, "!good.closer.com"),
+							},
+							Entity: "example",
+							URL:    mustURL("https://example.com"),
+						},
+						Suffix:   mkSrc(5, "!com"),
+						Wildcard: mkSrc(3, "*.com"),
+						Label:    "",
+					},
+					InvalidWildcardException{
+						Suffixes: Suffixes{
+							Source: mkSrc(2,
+								"// example : https://example.com",
+								"*.com",
+								"!good.com",
+								"!com",
+								"!bad.label.com",
+								"!.com",
+								"*.closer.com",
+								"!good.closer.com",
+							),
+							Header: []Source{
+								mkSrc(2, "// example : https://example.com"),
+							},
+							Entries: []Source{
+								mkSrc(3, "*.com"),
+								mkSrc(4, "!good.com"),
+								mkSrc(5, "!com"),
+								mkSrc(6, "!bad.label.com"),
+								mkSrc(7, "!.com"),
+								mkSrc(8, "*.closer.com"),
+								mkSrc(9, "!good.closer.com"),
+							},
+							Entity: "example",
+							URL:    mustURL("https://example.com"),
+						},
+						Suffix:   mkSrc(6, "!bad.label.com"),
+						Wildcard: mkSrc(3, "*.com"),
+						Label:    "bad.label",
+					},
+					EmptyWildcardException{
+						Suffixes: Suffixes{
+							Source: mkSrc(2,
+								"// example : https://example.com",
+								"*.com",
+								"!good.com",
+								"!com",
+								"!bad.label.com",
+								"!.com",
+								"*.closer.com",
+								"!good.closer.com",
+							),
+							Header: []Source{
+								mkSrc(2, "// example : https://example.com"),
+							},
+							Entries: []Source{
+								mkSrc(3, "*.com"),
+								mkSrc(4, "!good.com"),
+								mkSrc(5, "!com"),
+								mkSrc(6, "!bad.label.com"),
+								mkSrc(7, "!.com"),
+								mkSrc(8, "*.closer.com"),
+								mkSrc(9, "!good.closer.com"),
+							},
+							Entity: "example",
+							URL:    mustURL("https://example.com"),
+						},
+						Suffix: mkSrc(7, "!.com"),
+					},
+				},
+			},
+		},
+
+		{
+			name: "invalid_suffix_labels",
+			psl: byteLines(
+				"// ===BEGIN ICANN DOMAINS===",
+				"",
+				"// example : https://example.com",
+				"com",
+				"ok-label.com",
+				"_dmarc.com",
+				"*.-leading.com",
+				"!trailing-.com",
+				"has space.com",
+				"",
+				"// ===END ICANN DOMAINS===",
+			),
+			want: File{
+				Blocks: []Block{
+					StartSection{
+						Source: mkSrc(0, "// ===BEGIN ICANN DOMAINS==="),
+						Name:   "ICANN DOMAINS",
+					},
+					Suffixes{
+						Source: mkSrc(2,
+							"// example : https://example.com",
+							"com",
+							"ok-label.com",
+							"_dmarc.com",
+							"*.-leading.com",
+							"!trailing-.com",
+							"has space.com",
+						),
+						Header: []Source{
+							mkSrc(2, "// example : https://example.com"),
+						},
+						Entries: []Source{
+							mkSrc(3, "com"),
+							mkSrc(4, "ok-label.com"),
+							mkSrc(5, "_dmarc.com"),
+							mkSrc(6, "*.-leading.com"),
+							mkSrc(7, "!trailing-.com"),
+							mkSrc(8, "has space.com"),
+						},
+						Entity: "example",
+						URL:    mustURL("https://example.com"),
+					},
+					EndSection{
+						Source: mkSrc(10, "// ===END ICANN DOMAINS==="),
+						Name:   "ICANN DOMAINS",
+					},
+				},
+				Errors: []error{
+					OrphanedException{
+						Suffixes: Suffixes{
+							Source: mkSrc(2,
+								"// example : https://example.com",
+								"com",
+								"ok-label.com",
+								"_dmarc.com",
+								"*.-leading.com",
+								"!trailing-.com",
+								"has space.com",
+							),
+							Header: []Source{
+								mkSrc(2, "// example : https://example.com"),
+							},
+							Entries: []Source{
+								mkSrc(3, "com"),
+								mkSrc(4, "ok-label.com"),
+								mkSrc(5, "_dmarc.com"),
+								mkSrc(6, "*.-leading.com"),
+								mkSrc(7, "!trailing-.com"),
+								mkSrc(8, "has space.com"),
+							},
+							Entity: "example",
+							URL:    mustURL("https://example.com"),
+						},
+						Suffix: mkSrc(7, "!trailing-.com"),
+					},
+					InvalidSuffixLabel{
+						Suffixes: Suffixes{
+							Source: mkSrc(2,
+								"// example : https://example.com",
+								"com",
+								"ok-label.com",
+								"_dmarc.com",
+								"*.-leading.com",
+								"!trailing-.com",
+								"has space.com",
+							),
+							Header: []Source{
+								mkSrc(2, "// example : https://example.com"),
+							},
+							Entries: []Source{
+								mkSrc(3, "com"),
+								mkSrc(4, "ok-label.com"),
+								mkSrc(5, "_dmarc.com"),
+								mkSrc(6, "*.-leading.com"),
+								mkSrc(7, "!trailing-.com"),
+								mkSrc(8, "has space.com"),
+							},
+							Entity: "example",
+							URL:    mustURL("https://example.com"),
+						},
+						Suffix: mkSrc(5, "_dmarc.com"),
+						Label:  "_dmarc",
+					},
+					InvalidSuffixLabel{
+						Suffixes: Suffixes{
+							Source: mkSrc(2,
+								"// example : https://example.com",
+								"com",
+								"ok-label.com",
+								"_dmarc.com",
+								"*.-leading.com",
+								"!trailing-.com",
+								"has space.com",
+							),
+							Header: []Source{
+								mkSrc(2, "// example : https://example.com"),
+							},
+							Entries: []Source{
+								mkSrc(3, "com"),
+								mkSrc(4, "ok-label.com"),
+								mkSrc(5, "_dmarc.com"),
+								mkSrc(6, "*.-leading.com"),
+								mkSrc(7, "!trailing-.com"),
+								mkSrc(8, "has space.com"),
+							},
+							Entity: "example",
+							URL:    mustURL("https://example.com"),
+						},
+						Suffix: mkSrc(6, "*.-leading.com"),
+						Label:  "-leading",
+					},
+					InvalidSuffixLabel{
+						Suffixes: Suffixes{
+							Source: mkSrc(2,
+								"// example : https://example.com",
+								"com",
+								"ok-label.com",
+								"_dmarc.com",
+								"*.-leading.com",
+								"!trailing-.com",
+								"has space.com",
+							),
+							Header: []Source{
+								mkSrc(2, "// example : https://example.com"),
+							},
+							Entries: []Source{
+								mkSrc(3, "com"),
+								mkSrc(4, "ok-label.com"),
+								mkSrc(5, "_dmarc.com"),
+								mkSrc(6, "*.-leading.com"),
+								mkSrc(7, "!trailing-.com"),
+								mkSrc(8, "has space.com"),
+							},
+							Entity: "example",
+							URL:    mustURL("https://example.com"),
+						},
+						Suffix: mkSrc(7, "!trailing-.com"),
+						Label:  "trailing-",
+					},
+					InvalidSuffixLabel{
+						Suffixes: Suffixes{
+							Source: mkSrc(2,
+								"// example : https://example.com",
+								"com",
+								"ok-label.com",
+								"_dmarc.com",
+								"*.-leading.com",
+								"!trailing-.com",
+								"has space.com",
+							),
+							Header: []Source{
+								mkSrc(2, "// example : https://example.com"),
+							},
+							Entries: []Source{
+								mkSrc(3, "com"),
+								mkSrc(4, "ok-label.com"),
+								mkSrc(5, "_dmarc.com"),
+								mkSrc(6, "*.-leading.com"),
+								mkSrc(7, "!trailing-.com"),
+								mkSrc(8, "has space.com"),
+							},
+							Entity: "example",
+							URL:    mustURL("https://example.com"),
+						},
+						Suffix: mkSrc(8, "has space.com"),
+						Label:  "has space",
+					},
+				},
+			},
+		},
+
+		{
+			name: "suffix_wildcard_overlap",
+			psl: byteLines(
+				"// ===BEGIN ICANN DOMAINS===",
+				"",
+				"// example : https://example.com",
+				"com",
+				"*.com",
+				"*.other",
+				"!www.other",
+				"",
+				"// ===END ICANN DOMAINS===",
+			),
+			want: File{
+				Blocks: []Block{
+					StartSection{
+						Source: mkSrc(0, "// ===BEGIN ICANN DOMAINS==="),
+						Name:   "ICANN DOMAINS",
+					},
+					Suffixes{
+						Source: mkSrc(2,
+							"// example : https://example.com",
+							"com",
+							"*.com",
+							"*.other",
+							"!www.other",
+						),
+						Header: []Source{
+							mkSrc(2, "// example : https://example.com"),
+						},
+						Entries: []Source{
+							mkSrc(3, "com"),
+							mkSrc(4, "*.com"),
+							mkSrc(5, "*.other"),
+							mkSrc(6, "!www.other"),
+						},
+						Entity: "example",
+						URL:    mustURL("https://example.com"),
+					},
+					EndSection{
+						Source: mkSrc(8, "// ===END ICANN DOMAINS==="),
+						Name:   "ICANN DOMAINS",
+					},
+				},
+				Errors: []error{
+					SuffixWildcardOverlap{
+						Suffix:   mkSrc(3, "com"),
+						Wildcard: mkSrc(4, "*.com"),
+					},
+				},
+			},
+		},
+
+		{
+			name: "duplicate_maintainer",
+			psl: byteLines(
+				"// ===BEGIN PRIVATE DOMAINS===",
+				"",
+				"// DuckCorp Inc: https://example.com",
+				"// Submitted by Not A Duck <duck@example.com>",
+				"// Also Not A Duck <duck@EXAMPLE.com>, Other Duck <other@example.com>",
+				"// Backup: Duck@example.com",
+				"example.com",
+				"",
+				"// ===END PRIVATE DOMAINS===",
+			),
+			want: File{
+				Blocks: []Block{
+					StartSection{
+						Source: mkSrc(0, "// ===BEGIN PRIVATE DOMAINS==="),
+						Name:   "PRIVATE DOMAINS",
+					},
+					Suffixes{
+						Source: mkSrc(2,
+							"// DuckCorp Inc: https://example.com",
+							"// Submitted by Not A Duck <duck@example.com>",
+							"// Also Not A Duck <duck@EXAMPLE.com>, Other Duck <other@example.com>",
+							"// Backup: Duck@example.com",
+							"example.com",
+						),
+						Header: []Source{
+							mkSrc(2, "// DuckCorp Inc: https://example.com"),
+							mkSrc(3, "// Submitted by Not A Duck <duck@example.com>"),
+							mkSrc(4, "// Also Not A Duck <duck@EXAMPLE.com>, Other Duck <other@example.com>"),
+							mkSrc(5, "// Backup: Duck@example.com"),
+						},
+						Entries: []Source{
+							mkSrc(6, "example.com"),
+						},
+						Entity:    "DuckCorp Inc",
+						URL:       mustURL("https://example.com"),
+						Submitter: mustEmail("Not A Duck <duck@example.com>"),
+					},
+					EndSection{
+						Source: mkSrc(8, "// ===END PRIVATE DOMAINS==="),
+						Name:   "PRIVATE DOMAINS",
+					},
+				},
+				Errors: []error{
+					DuplicateMaintainer{
+						Suffixes: Suffixes{
+							Source: mkSrc(2,
+								"// DuckCorp Inc: https://example.com",
+								"// Submitted by Not A Duck <duck@example.com>",
+								"// Also Not A Duck <duck@EXAMPLE.com>, Other Duck <other@example.com>",
+								"// Backup: Duck@example.com",
+								"example.com",
+							),
+							Header: []Source{
+								mkSrc(2, "// DuckCorp Inc: https://example.com"),
+								mkSrc(3, "// Submitted by Not A Duck <duck@example.com>"),
+								mkSrc(4, "// Also Not A Duck <duck@EXAMPLE.com>, Other Duck <other@example.com>"),
+								mkSrc(5, "// Backup: Duck@example.com"),
+							},
+							Entries: []Source{
+								mkSrc(6, "example.com"),
+							},
+							Entity:    "DuckCorp Inc",
+							URL:       mustURL("https://example.com"),
+							Submitter: mustEmail("Not A Duck <duck@example.com>"),
+						},
+						Line:    mkSrc(4, "// Also Not A Duck <duck@EXAMPLE.com>, Other Duck <other@example.com>"),
+						Address: "duck@EXAMPLE.com",
+					},
+				},
+			},
+		},
+
+		{
+			name: "tld_in_private_section",
+			psl: byteLines(
+				"// ===BEGIN PRIVATE DOMAINS===",
+				"",
+				"// DuckCorp Inc: https://example.com",
+				"// Submitted by Not A Duck <duck@example.com>",
+				"example.com",
+				"duck",
+				"*.quack",
+				"",
+				"// ===END PRIVATE DOMAINS===",
+			),
+			want: File{
+				Blocks: []Block{
+					StartSection{
+						Source: mkSrc(0, "// ===BEGIN PRIVATE DOMAINS==="),
+						Name:   "PRIVATE DOMAINS",
+					},
+					Suffixes{
+						Source: mkSrc(2,
+							"// DuckCorp Inc: https://example.com",
+							"// Submitted by Not A Duck <duck@example.com>",
+							"example.com",
+							"duck",
+							"*.quack",
+						),
+						Header: []Source{
+							mkSrc(2, "// DuckCorp Inc: https://example.com"),
+							mkSrc(3, "// Submitted by Not A Duck <duck@example.com>"),
+						},
+						Entries: []Source{
+							mkSrc(4, "example.com"),
+							mkSrc(5, "duck"),
+							mkSrc(6, "*.quack"),
+						},
+						Entity:    "DuckCorp Inc",
+						URL:       mustURL("https://example.com"),
+						Submitter: mustEmail("Not A Duck <duck@example.com>"),
+					},
+					EndSection{
+						Source: mkSrc(8, "// ===END PRIVATE DOMAINS==="),
+						Name:   "PRIVATE DOMAINS",
+					},
+				},
+				Errors: []error{
+					TLDInPrivateSection{
+						Suffixes: Suffixes{
+							Source: mkSrc(2,
+								"// DuckCorp Inc: https://example.com",
+								"// Submitted by Not A Duck <duck@example.com>",
+								"example.com",
+								"duck",
+								"*.quack",
+							),
+							Header: []Source{
+								mkSrc(2, "// DuckCorp Inc: https://example.com"),
+								mkSrc(3, "// Submitted by Not A Duck <duck@example.com>"),
+							},
+							Entries: []Source{
+								mkSrc(4, "example.com"),
+								mkSrc(5, "duck"),
+								mkSrc(6, "*.quack"),
+							},
+							Entity:    "DuckCorp Inc",
+							URL:       mustURL("https://example.com"),
+							Submitter: mustEmail("Not A Duck <duck@example.com>"),
+						},
+						Suffix: mkSrc(5, "duck"),
+					},
+					TLDInPrivateSection{
+						Suffixes: Suffixes{
+							Source: mkSrc(2,
+								"// DuckCorp Inc: https://example.com",
+								"// Submitted by Not A Duck <duck@example.com>",
+								"example.com",
+								"duck",
+								"*.quack",
+							),
+							Header: []Source{
+								mkSrc(2, "// DuckCorp Inc: https://example.com"),
+								mkSrc(3, "// Submitted by Not A Duck <duck@example.com>"),
+							},
+							Entries: []Source{
+								mkSrc(4, "example.com"),
+								mkSrc(5, "duck"),
+								mkSrc(6, "*.quack"),
+							},
+							Entity:    "DuckCorp Inc",
+							URL:       mustURL("https://example.com"),
+							Submitter: mustEmail("Not A Duck <duck@example.com>"),
+						},
+						Suffix: mkSrc(6, "*.quack"),
+					},
+				},
+			},
+		},
+
+		{
+			name: "invalid_entity_name",
+			psl: byteLines(
+				"// ===BEGIN PRIVATE DOMAINS===",
+				"",
+				"// Duck\u200bCorp Inc: https://example.com",
+				"// Submitted by Not A Duck <duck@example.com>",
+				"example.com",
+				"",
+				"// ===END PRIVATE DOMAINS===",
+			),
+			want: File{
+				Blocks: []Block{
+					StartSection{
+						Source: mkSrc(0, "// ===BEGIN PRIVATE DOMAINS==="),
+						Name:   "PRIVATE DOMAINS",
+					},
+					Suffixes{
+						Source: mkSrc(2,
+							"// Duck\u200bCorp Inc: https://example.com",
+							"// Submitted by Not A Duck <duck@example.com>",
+							"example.com",
+						),
+						Header: []Source{
+							mkSrc(2, "// Duck\u200bCorp Inc: https://example.com"),
+							mkSrc(3, "// Submitted by Not A Duck <duck@example.com>"),
+						},
+						Entries: []Source{
+							mkSrc(4, "example.com"),
+						},
+						Entity:    "Duck\u200bCorp Inc",
+						URL:       mustURL("https://example.com"),
+						Submitter: mustEmail("Not A Duck <duck@example.com>"),
+					},
+					EndSection{
+						Source: mkSrc(6, "// ===END PRIVATE DOMAINS==="),
+						Name:   "PRIVATE DOMAINS",
+					},
+				},
+				Errors: []error{
+					InvalidEntityName{
+						Suffixes: Suffixes{
+							Source: mkSrc(2,
+								"// Duck\u200bCorp Inc: https://example.com",
+								"// Submitted by Not A Duck <duck@example.com>",
+								"example.com",
+							),
+							Header: []Source{
+								mkSrc(2, "// Duck\u200bCorp Inc: https://example.com"),
+								mkSrc(3, "// Submitted by Not A Duck <duck@example.com>"),
+							},
+							Entries: []Source{
+								mkSrc(4, "example.com"),
+							},
+							Entity:    "Duck\u200bCorp Inc",
+							URL:       mustURL("https://example.com"),
+							Submitter: mustEmail("Not A Duck <duck@example.com>"),
+						},
+						Char:   '\u200b',
+						Offset: 4,
+					},
+				},
+			},
+		},
+
+		{
+			name: "missing_submission_reference",
+			psl: byteLines(
+				"// ===BEGIN PRIVATE DOMAINS===",
+				"",
+				"// DuckCorp Inc: https://example.com",
+				"// Not A Duck <duck@example.com>",
+				"example.com",
+				"",
+				"// Goose LLC: https://example.org",
+				"// Goose <goose@example.org>",
+				"// https://github.com/publicsuffix/list/pull/1234",
+				"example.org",
+				"",
+				"// ===END PRIVATE DOMAINS===",
+			),
+			want: File{
+				Blocks: []Block{
+					StartSection{
+						Source: mkSrc(0, "// ===BEGIN PRIVATE DOMAINS==="),
+						Name:   "PRIVATE DOMAINS",
+					},
+					Suffixes{
+						Source: mkSrc(2,
+							"// DuckCorp Inc: https://example.com",
+							"// Not A Duck <duck@example.com>",
+							"example.com",
+						),
+						Header: []Source{
+							mkSrc(2, "// DuckCorp Inc: https://example.com"),
+							mkSrc(3, "// Not A Duck <duck@example.com>"),
+						},
+						Entries: []Source{
+							mkSrc(4, "example.com"),
+						},
+						Entity:    "DuckCorp Inc",
+						URL:       mustURL("https://example.com"),
+						Submitter: mustEmail("Not A Duck <duck@example.com>"),
+					},
+					Suffixes{
+						Source: mkSrc(6,
+							"// Goose LLC: https://example.org",
+							"// Goose <goose@example.org>",
+							"// https://github.com/publicsuffix/list/pull/1234",
+							"example.org",
+						),
+						Header: []Source{
+							mkSrc(6, "// Goose LLC: https://example.org"),
+							mkSrc(7, "// Goose <goose@example.org>"),
+							mkSrc(8, "// https://github.com/publicsuffix/list/pull/1234"),
+						},
+						Entries: []Source{
+							mkSrc(9, "example.org"),
+						},
+						Entity:    "Goose LLC",
+						URL:       mustURL("https://example.org"),
+						Submitter: mustEmail("Goose <goose@example.org>"),
+					},
+					EndSection{
+						Source: mkSrc(11, "// ===END PRIVATE DOMAINS==="),
+						Name:   "PRIVATE DOMAINS",
+					},
+				},
+				Errors: []error{
+					MissingSubmissionReference{
+						Suffixes: Suffixes{
+							Source: mkSrc(2,
+								"// DuckCorp Inc: https://example.com",
+								"// Not A Duck <duck@example.com>",
+								"example.com",
+							),
+							Header: []Source{
+								mkSrc(2, "// DuckCorp Inc: https://example.com"),
+								mkSrc(3, "// Not A Duck <duck@example.com>"),
+							},
+							Entries: []Source{
+								mkSrc(4, "example.com"),
+							},
+							Entity:    "DuckCorp Inc",
+							URL:       mustURL("https://example.com"),
+							Submitter: mustEmail("Not A Duck <duck@example.com>"),
+						},
+					},
+				},
+			},
+		},
+
+		{
+			name: "legacy_error_downgrade",
+			psl: byteLines(
+				"// https://example.com",
+				"example.com",
+			),
+			downgradeToWarning: func(e error) bool {
+				return true
+			},
+			want: File{
+				Blocks: []Block{
+					Suffixes{
+						Source: mkSrc(0,
+							"// https://example.com",
+							"example.com",
+						),
+						Header: []Source{
+							mkSrc(0, "// https://example.com"),
+						},
+						Entries: []Source{
+							mkSrc(1, "example.com"),
+						},
+						URL: mustURL("https://example.com"),
+					},
+				},
+				Warnings: []error{
+					MissingEntityName{
+						Suffixes: Suffixes{
+							Source: mkSrc(0,
+								"// https://example.com",
+								"example.com",
+							),
+							Header: []Source{
+								mkSrc(0, "// https://example.com"),
+							},
+							Entries: []Source{
+								mkSrc(1, "example.com"),
+							},
+							URL: mustURL("https://example.com"),
+						},
+					},
+				},
+			},
+		},
+
+		{
+			// Regression test for Future Versatile Group, who use a
+			// unicode fullwidth colon in their header.
+			name: "unicode_colon",
+			psl: byteLines(
+				"// Future Versatile Group：https://example.org",
+				"example.com",
+			),
+			want: File{
+				Blocks: []Block{
+					Suffixes{
+						Source: mkSrc(0, "// Future Versatile Group：https://example.org", "example.com"),
+						Header: []Source{
+							mkSrc(0, "// Future Versatile Group：https://example.org"),
+						},
+						Entries: []Source{
+							mkSrc(1, "example.com"),
+						},
+						Entity: "Future Versatile Group",
+						URL:    mustURL("https://example.org"),
+					},
+				},
+			},
+		},
+
+		{
+			// Regression test for a few blocks that start with "name
+			// (url)" instead of the more common "name: url".
+			name: "url_in_parens",
+			psl: byteLines(
+				"// Parens Appreciation Society (https://example.org)",
+				"example.com",
+			),
+			want: File{
+				Blocks: []Block{
+					Suffixes{
+						Source: mkSrc(0, "// Parens Appreciation Society (https://example.org)", "example.com"),
+						Header: []Source{
+							mkSrc(0, "// Parens Appreciation Society (https://example.org)"),
+						},
+						Entries: []Source{
+							mkSrc(1, "example.com"),
+						},
+						Entity: "Parens Appreciation Society",
+						URL:    mustURL("https://example.org"),
+					},
+				},
+			},
+		},
+
+		{
+			// Variation on the previous, some blocks in the "name
+			// (url)" style don't have a scheme on their URL, so
+			// require a bit more fudging to parse.
+			name: "url_in_parens_no_scheme",
+			psl: byteLines(
+				"// Parens Appreciation Society (hostyhosting.com)",
+				"example.com",
+				"",
+				"// Parens Policy Panel (www.task.gda.pl/uslugi/dns)",
+				"policy.example.org",
+			),
+			want: File{
+				Blocks: []Block{
+					Suffixes{
+						Source: mkSrc(0, "// Parens Appreciation Society (hostyhosting.com)", "example.com"),
+						Header: []Source{
+							mkSrc(0, "// Parens Appreciation Society (hostyhosting.com)"),
+						},
+						Entries: []Source{
+							mkSrc(1, "example.com"),
+						},
+						Entity: "Parens Appreciation Society",
+						URL:    mustURL("https://hostyhosting.com"),
+					},
+					Suffixes{
+						Source: mkSrc(3, "// Parens Policy Panel (www.task.gda.pl/uslugi/dns)", "policy.example.org"),
+						Header: []Source{
+							mkSrc(3, "// Parens Policy Panel (www.task.gda.pl/uslugi/dns)"),
+						},
+						Entries: []Source{
+							mkSrc(4, "policy.example.org"),
+						},
+						Entity: "Parens Policy Panel",
+						URL:    mustURL("https://www.task.gda.pl/uslugi/dns"),
+					},
+				},
+			},
+		},
+
+		{
+			// Regression test for a sneaky bug during development:
+			// when an entity name is found when parsing Suffixes
+			// headers, don't keep trying to find it in subsequent
+			// lines, or you might overwrite the correct answer with
+			// someething else that happens to have the right shape.
+			name: "accept_first_valid_entity",
+			psl: byteLines(
+				"// cd : https://en.wikipedia.org/wiki/.cd",
+				"// see also: https://www.nic.cd/domain/insertDomain_2.jsp?act=1",
+				"cd",
+			),
+			want: File{
+				Blocks: []Block{
+					Suffixes{
+						Source: mkSrc(0,
+							"// cd : https://en.wikipedia.org/wiki/.cd",
+							"// see also: https://www.nic.cd/domain/insertDomain_2.jsp?act=1",
+							"cd",
+						),
+						Header: []Source{
+							mkSrc(0, "// cd : https://en.wikipedia.org/wiki/.cd"),
+							mkSrc(1, "// see also: https://www.nic.cd/domain/insertDomain_2.jsp?act=1"),
+						},
+						Entries: []Source{
+							mkSrc(2, "cd"),
+						},
+						Entity: "cd",
+						URL:    mustURL("https://en.wikipedia.org/wiki/.cd"),
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			exc := test.downgradeToWarning
+			if exc == nil {
 				// use real exceptions if the test doesn't provide something else
 				exc = downgradeToWarning
 			}
-			got := parseWithExceptions(test.psl, exc)
-			checkDiff(t, "parse result", got, &test.want)
-		})
+			// byteLines doesn't end the input with a newline like real
+			// PSL files, add one so that every case doesn't have to
+			// expect a TrailingNewlineError.
+			got := parseWithExceptions(append(test.psl, '\n'), "", exc)
+			checkDiff(t, "parse result", got, &test.want)
+		})
+	}
+}
+
+// mustURL returns the given string as a URL, or panics if not a URL.
+func mustURL(s string) *url.URL {
+	u, err := url.Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+// mustEmail returns the given string as an RFC 5322 address, or
+// panics if the parse fails.
+func mustEmail(s string) *mail.Address {
+	a, err := mail.ParseAddress(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// mkSrc returns a Source with the given start, end, and dedented text.
+func mkSrc(start int, lines ...string) Source {
+	return Source{
+		lineOffset: start,
+		lines:      lines,
+	}
+}
+
+// TestParseRealList checks that the real public suffix list can parse
+// without errors.
+func TestParseRealList(t *testing.T) {
+	bs, err := os.ReadFile("../../../public_suffix_list.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := Parse(bs)
+
+	for _, err := range f.Errors {
+		t.Errorf("Parse error: %v", err)
+	}
+}
+
+// TestRoundtripRealList checks that concatenating the source text of
+// all top-level blocks, with appropriate additional blank lines,
+// exactly reproduces the source text that was parsed. Effectively,
+// this is a "prove that the parser didn't discard any bytes" check.
+func TestRoundtripRealList(t *testing.T) {
+	bs, err := os.ReadFile("../../../public_suffix_list.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := Parse(bs)
+
+	if len(f.Errors) > 0 {
+		t.Fatal("Parse errors, not attempting to roundtrip")
+	}
+
+	prevLine := 0
+	var rebuilt bytes.Buffer
+	for _, block := range f.Blocks {
+		src := block.source()
+		if src.lineOffset < prevLine {
+			t.Fatalf("ordering error: previous block ended at %d but this block starts at %d:\n%s", prevLine, src.lineOffset, src.Text())
+		}
+		for prevLine < src.lineOffset {
+			rebuilt.WriteByte('\n')
+			prevLine++
+		}
+		rebuilt.WriteString(src.Text())
+		rebuilt.WriteByte('\n')
+		prevLine = src.lineOffset + len(src.lines)
+	}
+
+	got := strings.Split(strings.TrimSpace(rebuilt.String()), "\n")
+	want := strings.Split(strings.TrimSpace(string(bs)), "\n")
+
+	if diff := diff.Diff(want, got); diff != "" {
+		t.Errorf("roundtrip failed (-want +got):\n%s", diff)
+	}
+}
+
+// TestRoundtripRealListDetailed is like the prior round-tripping
+// test, but Suffix blocks are written out using their
+// Header/Entries/InlineComments fields, again as proof that no suffix
+// block elements were lost during parsing.
+func TestRoundtripRealListDetailed(t *testing.T) {
+	bs, err := os.ReadFile("../../../public_suffix_list.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := Parse(bs)
+
+	if len(f.Errors) > 0 {
+		t.Fatal("Parse errors, not attempting to roundtrip")
+	}
+
+	prevLine := 0
+	var rebuilt bytes.Buffer
+	for _, block := range f.Blocks {
+		srcs := []Source{block.source()}
+		if v, ok := block.(Suffixes); ok {
+			srcs = []Source{}
+			for _, h := range v.Header {
+				srcs = append(srcs, h)
+			}
+			for _, e := range v.Entries {
+				srcs = append(srcs, e)
+			}
+			for _, c := range v.InlineComments {
+				srcs = append(srcs, c)
+			}
+			slices.SortFunc(srcs, func(a, b Source) int {
+				return cmp.Compare(a.lineOffset, b.lineOffset)
+			})
+		}
+
+		for _, src := range srcs {
+			if src.lineOffset < prevLine {
+				t.Fatalf("ordering error: previous block ended at %d but this block starts at %d:\n%s", prevLine, src.lineOffset, src.Text())
+			}
+			for prevLine < src.lineOffset {
+				rebuilt.WriteByte('\n')
+				prevLine++
+			}
+			rebuilt.WriteString(src.Text())
+			rebuilt.WriteByte('\n')
+			prevLine = src.lineOffset + len(src.lines)
+		}
+	}
+
+	got := strings.Split(strings.TrimSpace(rebuilt.String()), "\n")
+	want := strings.Split(strings.TrimSpace(string(bs)), "\n")
+
+	if diff := diff.Diff(want, got); diff != "" {
+		t.Errorf("roundtrip failed (-want +got):\n%s", diff)
+	}
+}
+
+func TestDNSLengthLimits(t *testing.T) {
+	t.Parallel()
+
+	// Labels and suffixes right at the limit are fine, one more octet
+	// is an error.
+	label63 := strings.Repeat("a", 63)
+	label64 := strings.Repeat("a", 64)
+	// 57 and 58 characters respectively, but 63 and 64 octets in
+	// punycode.
+	idn63 := strings.Repeat("ü", 57)
+	idn64 := strings.Repeat("ü", 58)
+	// 3 labels of 63 octets with their dots make 192 octets, and
+	// ".com" 4 more. A 57 or 58 octet label makes up the rest.
+	long253 := strings.Repeat(label63+".", 3) + strings.Repeat("x", 57) + ".com"
+	long254 := strings.Repeat(label63+".", 3) + strings.Repeat("x", 58) + ".com"
+	if len(long253) != 253 || len(long254) != 254 {
+		t.Fatalf("bad test setup, suffix lengths are %d and %d", len(long253), len(long254))
+	}
+
+	in := byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// example : https://example.com",
+		"com",
+		label63+".com",
+		label64+".com",
+		idn63+".com",
+		idn64+".com",
+		long253,
+		long254,
+		"!"+label64+".com",
+		"",
+		"// ===END ICANN DOMAINS===",
+	)
+
+	var got []string
+	for _, err := range Parse(in).Errors {
+		switch v := err.(type) {
+		case SuffixTooLong:
+			got = append(got, fmt.Sprintf("suffix line %d: %d", v.Suffix.lineOffset+1, v.Length))
+		case LabelTooLong:
+			got = append(got, fmt.Sprintf("label line %d: %d", v.Suffix.lineOffset+1, len(v.Label)))
+		}
+	}
+	want := []string{
+		"label line 6: 64",
+		"label line 8: 64",
+		"suffix line 10: 254",
+		"label line 11: 64",
+	}
+	checkDiff(t, "length errors", got, want)
+}
+
+func TestParseReader(t *testing.T) {
+	t.Parallel()
+
+	in := byteLines(
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc: https://example.com",
+		"example.com",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	)
+	f, err := ParseReader(bytes.NewReader(in), "psl.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, block := range f.Blocks {
+		if got := block.source().Path(); got != "psl.dat" {
+			t.Errorf("block at %s has path %q, want %q", block.source().LocationString(), got, "psl.dat")
+		}
+	}
+
+	if len(f.Errors) != 1 {
+		t.Fatalf("got %d errors, want 1: %v", len(f.Errors), f.Errors)
+	}
+	if got, want := f.Errors[0].Error(), "psl.dat:3-4"; !strings.Contains(got, want) {
+		t.Errorf("error %q does not mention location %q", got, want)
+	}
+	info := Describe("", f.Errors)
+	if got, want := info[0].Path, "psl.dat"; got != want {
+		t.Errorf("Describe path is %q, want %q", got, want)
+	}
+}
+
+func TestParseStream(t *testing.T) {
+	t.Parallel()
+
+	in := byteLines(
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc: https://example.com",
+		"example.com",
+		"",
+		"// Submitted by Not A Duck <duck@example.com>",
+		"example.org",
+		"",
+		"// ===END ICANN DOMAINS===",
+	)
+
+	errs, result := ParseStream(context.Background(), in, "psl.dat", Options{})
+	var got []error
+	for err := range errs {
+		got = append(got, err)
+	}
+	f := result()
+	want := ParseWithOptions(in, "psl.dat", Options{}).Errors
+	if len(want) == 0 {
+		t.Fatal("test input has no errors")
+	}
+	checkDiff(t, "streamed errors", got, want)
+	checkDiff(t, "stream result errors", f.Errors, want)
+}
+
+func TestParseStreamCancel(t *testing.T) {
+	t.Parallel()
+
+	in := byteLines(
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc: https://example.com",
+		"example.com",
+		"",
+		"// Submitted by Not A Duck <duck@example.com>",
+		"example.org",
+		"",
+		"// ===END ICANN DOMAINS===",
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs, _ := ParseStream(ctx, in, "", Options{})
+	if _, ok := <-errs; !ok {
+		t.Fatal("stream closed without errors")
+	}
+	cancel()
+
+	// The parse must not block on sending the remaining errors, and
+	// must close the channel once it finishes.
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case _, ok := <-errs:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("stream not closed after cancel")
+		}
+	}
+}
+
+func TestContactInfoExemptions(t *testing.T) {
+	t.Parallel()
+
+	var got []string
+	for _, source := range ContactInfoExemptions() {
+		f := Parse([]byte(source))
+		blocks := f.AllSuffixBlocks()
+		if len(blocks) != 1 {
+			t.Errorf("exemption is not a single block of suffixes:\n%s", source)
+			continue
+		}
+		if !IsExemptFromContactInfo(blocks[0]) {
+			t.Errorf("exemption does not exempt its own block:\n%s", source)
+		}
+		got = append(got, blocks[0].Entity)
+	}
+	want := []string{
+		"611coin",
+		"c.la",
+		"co.ca",
+		"DynDNS.com",
+		"Hashbang",
+		"HostyHosting",
+		"info.at",
+		".KRD",
+		"Michau Enterprises Limited",
+		"Nicolaus Copernicus University in Torun - MSK TORMAN",
+		"TASK geographical domains",
+		"CoDNS B.V.",
+		".pl domains (grandfathered)",
+		"QA2",
+	}
+	checkDiff(t, "exempt entities", got, want)
+
+	block := lines(
+		"// DuckCorp Inc : https://example.com",
+		"example.com",
+	)
+	missingEmails := func(errs []error) int {
+		n := 0
+		for _, err := range errs {
+			if _, ok := err.(MissingEntityEmail); ok {
+				n++
+			}
+		}
+		return n
+	}
+	in := byteLines(
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		block,
+		"",
+		"// ===END PRIVATE DOMAINS===",
+		"",
+	)
+	f := Parse(in)
+	if IsExemptFromContactInfo(f.AllSuffixBlocks()[0]) || missingEmails(f.Errors) != 1 {
+		t.Fatal("block is exempt without an extra exemption")
+	}
+	f = ParseWithOptions(in, "", Options{ContactInfoExemptions: []string{block}})
+	if missingEmails(f.Errors) != 0 || missingEmails(f.Warnings) != 1 {
+		t.Errorf("block is not exempt with an extra exemption: errors %v, warnings %v", f.Errors, f.Warnings)
+	}
+	// Extra exemptions only apply to the parse they are passed to.
+	if f := Parse(in); missingEmails(f.Errors) != 1 {
+		t.Error("extra exemption leaked into a later parse")
+	}
+}
+
+// TestExceptionsStillNecessary checks that all the exceptions in
+// exeptions.go are still needed to parse the PSL without errors.
+func TestExceptionsStillNecessary(t *testing.T) {
+	bs, err := os.ReadFile("../../../public_suffix_list.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forEachOmitted(missingEmail, func(omitted string, trimmed []string) {
+		old := missingEmail
+		defer func() { missingEmail = old }()
+		missingEmail = trimmed
+
+		f := Parse(bs)
+		if len(f.Errors) == 0 {
+			t.Errorf("missingEmail exception no longer necessary:\n%s", omitted)
+		}
+	})
+
+	forEachOmitted(duplicateEntityName, func(omitted string, trimmed []string) {
+		old := duplicateEntityName
+		defer func() { duplicateEntityName = old }()
+		duplicateEntityName = trimmed
+
+		f := Parse(bs)
+		if len(f.Errors) == 0 {
+			t.Errorf("duplicateEntityName exception no longer necessary:\n%s", omitted)
+		}
+	})
+
+	forEachOmitted(emptyEntity, func(omitted string, trimmed []string) {
+		old := emptyEntity
+		defer func() { emptyEntity = old }()
+		emptyEntity = trimmed
+
+		f := Parse(bs)
+		if len(f.Errors) == 0 {
+			t.Errorf("emptyEntity exception no longer necessary:\n%s", omitted)
+		}
+	})
+
+	forEachOmitted(missingSubmissionReference, func(omitted string, trimmed []string) {
+		old := missingSubmissionReference
+		defer func() { missingSubmissionReference = old }()
+		missingSubmissionReference = trimmed
+
+		f := Parse(bs)
+		if len(f.Errors) == 0 {
+			t.Errorf("missingSubmissionReference exception no longer necessary:\n%s", omitted)
+		}
+	})
+}
+
+func forEachOmitted(exceptions []string, fn func(string, []string)) {
+	for i := range exceptions {
+		next := append([]string(nil), exceptions[:i]...)
+		next = append(next, exceptions[i+1:]...)
+		fn(exceptions[i], next)
+	}
+}
+
+func TestPunycodeLabels(t *testing.T) {
+	t.Parallel()
+
+	in := byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// example : https://example.com",
+		"example",
+		"xn--bcher-kva.example",
+		"xn--.example",
+		"xn--abc-.example",
+		"xn--ab-cd.example",
+		"xn--a.example",
+		"*.xn--bcher-kv.example",
+		"XN--BCHER-KVA.example",
+		"xn--bücher.example",
+		"xn--xn--bcher-kva.example",
+		"",
+		"// ===END ICANN DOMAINS===",
+	)
+
+	var got []string
+	for _, err := range Parse(in).Errors {
+		switch v := err.(type) {
+		case InvalidPunycode:
+			got = append(got, fmt.Sprintf("line %d: %s %q", v.Suffix.lineOffset+1, v.Label, v.Decoded))
+		case NonCanonicalSuffix:
+			got = append(got, fmt.Sprintf("line %d: non-canonical", v.Suffix.lineOffset+1))
+		}
+	}
+	want := []string{
+		// Valid punycode suffixes, in any case, are only
+		// non-canonical.
+		"line 5: non-canonical",
+		"line 11: non-canonical",
+		"line 6: xn-- \"\"",
+		"line 7: xn--abc- \"abc\"",
+		"line 8: xn--ab-cd \"\"",
+		"line 9: xn--a \"\\u0080\"",
+		"line 10: xn--bcher-kv \"\"",
+		// Copy-paste mistakes that mangle the ACE prefix.
+		"line 12: xn--bücher \"\"",
+		"line 13: xn--xn--bcher-kva \"xn--bÊcher\"",
+	}
+	checkDiff(t, "punycode errors", got, want)
+}
+
+func TestValidateSuffixes(t *testing.T) {
+	t.Parallel()
+
+	// Two blocks that are each fine on their own, but share an
+	// entity name. Only the single-block problems of the second
+	// block should be reported.
+	f := Parse(byteLines(
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc: https://example.com",
+		"// Submitted by Not A Duck <duck@example.com>",
+		"example.com",
+		"",
+		"// DuckCorp Inc: https://example.org",
+		"Example.org",
+		"b.example.org",
+		"a.example.org",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	))
+	blocks := f.AllSuffixBlocks()
+	if len(blocks) != 2 {
+		t.Fatalf("parsed %d blocks, want 2", len(blocks))
+	}
+
+	errs, warnings := ValidateSuffixes(blocks[0], "PRIVATE DOMAINS", Options{})
+	checkDiff(t, "first block errors", errs, []error(nil))
+	checkDiff(t, "first block warnings", warnings, []error(nil))
+
+	errs, warnings = ValidateSuffixes(blocks[1], "PRIVATE DOMAINS", Options{})
+	var got []string
+	for _, err := range append(errs, warnings...) {
+		got = append(got, errorType(err))
+	}
+	want := []string{
+		"missing_entity_email",
+		"non_canonical_suffix",
+		"unsorted_suffixes",
+	}
+	checkDiff(t, "second block findings", got, want)
+
+	// Outside the private section, the private-only checks don't
+	// apply.
+	errs, _ = ValidateSuffixes(blocks[1], "ICANN DOMAINS", Options{})
+	got = nil
+	for _, err := range errs {
+		got = append(got, errorType(err))
+	}
+	checkDiff(t, "second block as ICANN", got, []string{"non_canonical_suffix"})
+}
+
+func TestSuffixInWrongSection(t *testing.T) {
+	t.Parallel()
+
+	in := byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// com : https://example.com",
+		"com",
+		"",
+		"// ck : https://example.com",
+		"*.ck",
+		"",
+		// Under another block's TLD, so probably private.
+		"// Brand : https://brand.com",
+		"brand.com",
+		"foo.ck",
+		"",
+		// Lists its own TLD alongside second-level names.
+		"// org : https://example.org",
+		"org",
+		"gov.com",
+		"",
+		// Under a TLD that no block lists.
+		"// za : https://example.za",
+		"co.za",
+		"",
+		// Too large to be a single private submission.
+		"// com geographic names : https://example.com",
+		"a.com",
+		"b.com",
+		"c.com",
+		"d.com",
+		"e.com",
+		"f.com",
+		"",
+		"// ===END ICANN DOMAINS===",
+	)
+
+	misfiled := func(opts Options) []string {
+		var ret []string
+		for _, err := range ParseWithOptions(in, "", opts).Warnings {
+			if v, ok := err.(SuffixInWrongSection); ok {
+				ret = append(ret, fmt.Sprintf("%s (%s)", v.Suffixes.Entity, v.Owner.Entity))
+			}
+		}
+		return ret
 	}
+	checkDiff(t, "misfiled blocks", misfiled(Options{}), []string{"Brand (ck)"})
+	checkDiff(t, "misfiled blocks with a larger limit", misfiled(Options{MaxMisfiledICANNEntries: 6}), []string{"Brand (ck)", "com geographic names (com)"})
+	checkDiff(t, "misfiled blocks with the check disabled", misfiled(Options{MaxMisfiledICANNEntries: -1}), []string(nil))
 }
 
-// mustURL returns the given string as a URL, or panics if not a URL.
-func mustURL(s string) *url.URL {
-	u, err := url.Parse(s)
-	if err != nil {
-		panic(err)
+func TestUnknownEmailTLDs(t *testing.T) {
+	t.Parallel()
+
+	in := byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// com : https://example.com",
+		"com",
+		"",
+		"// рф : https://example.com",
+		"рф",
+		"",
+		"// ===END ICANN DOMAINS===",
+		"",
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// Good : https://good.com",
+		"// Submitted by Duck <duck@good.com>",
+		"good.com",
+		"",
+		"// Typo : https://typo.com",
+		"// Submitted by Duck <duck@typo.con>",
+		"typo.com",
+		"",
+		"// IDN : https://idn.com",
+		"// Submitted by Duck <duck@xn--d1acufc.XN--P1AI>",
+		"idn.com",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	)
+
+	var got []string
+	for _, err := range Parse(in).Warnings {
+		if v, ok := err.(InvalidEmailTLD); ok {
+			got = append(got, v.Suffixes.Submitter.Address)
+		}
 	}
-	return u
+	want := []string{"duck@typo.con"}
+	checkDiff(t, "unknown email TLDs", got, want)
 }
 
-// mustEmail returns the given string as an RFC 5322 address, or
-// panics if the parse fails.
-func mustEmail(s string) *mail.Address {
-	a, err := mail.ParseAddress(s)
-	if err != nil {
-		panic(err)
+func TestSectionErrorOrder(t *testing.T) {
+	t.Parallel()
+
+	in := byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"// ===BEGIN PRIVATE DOMAIN",
+		"// ===END PRIVATE DOMAINS===",
+		"// ===END ICANN DOMAINS",
+		"// ===BEGIN ICANN DOMAINS===",
+		"// ===END PRIVATE DOMAINS===",
+		"// ===BEGIN PRIVATE DOMAINS===",
+	)
+	want := []string{
+		// Line 2
+		"nested_section",
+		"unknown_section_name",
+		"unterminated_section_marker",
+		// Line 3
+		"mismatched_section",
+		// Line 4
+		"unstarted_section",
+		"unterminated_section_marker",
+		// Line 5
+		"duplicate_section",
+		// Line 6
+		"mismatched_section",
+		// End of file
+		"unclosed_section",
+	}
+
+	// Run several times, to catch any dependency on map iteration
+	// order.
+	for i := 0; i < 10; i++ {
+		var got []string
+		for _, err := range Parse(in).Errors {
+			got = append(got, errorType(err))
+		}
+		checkDiff(t, "section errors", got, want)
 	}
-	return a
 }
 
-// mkSrc returns a Source with the given start, end, and dedented text.
-func mkSrc(start int, lines ...string) Source {
-	return Source{
-		lineOffset: start,
-		lines:      lines,
+func TestSharedMaintainers(t *testing.T) {
+	t.Parallel()
+
+	in := byteLines(
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc: https://example.com",
+		"// Submitted by Not A Duck <duck@example.com>",
+		"example.com",
+		"",
+		"// Duck Corp: https://example.net",
+		"// Submitted by Not A Duck <duck@EXAMPLE.com>",
+		"example.net",
+		"",
+		// Same name as the first block, so not reported again.
+		"// DuckCorp  Inc: https://example.org",
+		"// Submitted by Not A Duck <duck@example.com>",
+		"example.org",
+		"",
+		"// Goose LLC: https://goose.example",
+		"// Submitted by Goose <goose@example.com>",
+		"goose.example",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	)
+
+	var got []string
+	for _, err := range Parse(in).Warnings {
+		if v, ok := err.(SharedMaintainer); ok {
+			for _, block := range v.Entities {
+				got = append(got, fmt.Sprintf("%s: %s at %s", v.Address, block.Entity, block.LocationString()))
+			}
+		}
 	}
+	want := []string{
+		"duck@example.com: DuckCorp Inc at lines 3-5",
+		"duck@example.com: Duck Corp at lines 7-9",
+	}
+	checkDiff(t, "shared maintainers", got, want)
 }
 
-// TestParseRealList checks that the real public suffix list can parse
-// without errors.
-func TestParseRealList(t *testing.T) {
-	bs, err := os.ReadFile("../../../public_suffix_list.dat")
-	if err != nil {
-		t.Fatal(err)
+func TestParseWithReference(t *testing.T) {
+	t.Parallel()
+
+	ref := Parse(byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// com : https://example.com",
+		"com",
+		"",
+		"// ===END ICANN DOMAINS===",
+	))
+	// A change that adds a private suffix under a made up TLD, and
+	// the TLD to the ICANN section to make it pass.
+	in := byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// com : https://example.com",
+		"com",
+		"",
+		"// evil : https://example.com",
+		"evil",
+		"",
+		"// ===END ICANN DOMAINS===",
+		"",
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc: https://example.com",
+		"// Submitted by Not A Duck <duck@example.com>",
+		"example.com",
+		"duck.evil",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	)
+
+	unknownTLDs := func(f *File) []string {
+		var ret []string
+		for _, err := range f.Errors {
+			if v, ok := err.(UnknownTLD); ok {
+				ret = append(ret, v.Suffix.Text())
+			}
+		}
+		return ret
 	}
+	checkDiff(t, "unknown TLDs without reference", unknownTLDs(Parse(in)), []string(nil))
+	checkDiff(t, "unknown TLDs with nil reference", unknownTLDs(ParseWithReference(in, "", nil)), []string(nil))
+	checkDiff(t, "unknown TLDs with reference", unknownTLDs(ParseWithReference(in, "", ref)), []string{"duck.evil"})
+}
 
-	f := Parse(bs)
+func TestParseWithReferenceDisjointSections(t *testing.T) {
+	t.Parallel()
 
-	for _, err := range f.Errors {
-		t.Errorf("Parse error: %v", err)
+	ref := Parse(byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// com : https://example.com",
+		"com",
+		"",
+		"// ===END ICANN DOMAINS===",
+		"",
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// Zeta Corp: https://zeta.com",
+		"// Submitted by Zeta Admin <admin@zeta.com>",
+		"zeta.com",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	))
+	// A change that adds a private suffix to the file's own ICANN
+	// section. The reference's ICANN section doesn't list it, but
+	// the file's does.
+	in := byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// com : https://example.com",
+		"com",
+		"zeta.com",
+		"",
+		"// ===END ICANN DOMAINS===",
+		"",
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// Zeta Corp: https://zeta.com",
+		"// Submitted by Zeta Admin <admin@zeta.com>",
+		"zeta.com",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	)
+
+	duplicates := func(f *File) []string {
+		var ret []string
+		for _, err := range f.Errors {
+			if v, ok := err.(CrossSectionDuplicate); ok {
+				ret = append(ret, fmt.Sprintf("%s %s", v.Private.Text(), v.ICANN.LocationString()))
+			}
+		}
+		return ret
 	}
+	want := []string{"zeta.com psl.dat:5"}
+	checkDiff(t, "cross-section duplicates without reference", duplicates(ParseWithReference(in, "psl.dat", nil)), want)
+	checkDiff(t, "cross-section duplicates with reference", duplicates(ParseWithReference(in, "psl.dat", ref)), want)
 }
 
-// TestRoundtripRealList checks that concatenating the source text of
-// all top-level blocks, with appropriate additional blank lines,
-// exactly reproduces the source text that was parsed. Effectively,
-// this is a "prove that the parser didn't discard any bytes" check.
-func TestRoundtripRealList(t *testing.T) {
-	bs, err := os.ReadFile("../../../public_suffix_list.dat")
-	if err != nil {
-		t.Fatal(err)
+func TestEmptyWildcardExceptions(t *testing.T) {
+	t.Parallel()
+
+	in := byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// example : https://example.com",
+		"*.com",
+		"!",
+		"!a..com",
+		"!good.com",
+		"",
+		"// ===END ICANN DOMAINS===",
+	)
+
+	var got []string
+	for _, err := range Parse(in).Errors {
+		got = append(got, fmt.Sprintf("%s %s", errorType(err), err.(interface{ suffix() Source }).suffix().Text()))
 	}
-	f := Parse(bs)
+	want := []string{
+		"empty_wildcard_exception !",
+		"empty_wildcard_exception !a..com",
+	}
+	checkDiff(t, "errors", got, want)
+}
 
-	if len(f.Errors) > 0 {
-		t.Fatal("Parse errors, not attempting to roundtrip")
+func TestMergedEntities(t *testing.T) {
+	t.Parallel()
+
+	in := byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// com : https://example.com",
+		"com",
+		"// See also : https://example.com/policy",
+		"gov.com",
+		"",
+		"// ===END ICANN DOMAINS===",
+		"",
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc: https://example.com",
+		"// Submitted by Not A Duck <duck@example.com>",
+		"example.com",
+		"// Ducks on the pond",
+		"pond.example.com",
+		"// Goose LLC: https://example.org",
+		"// Submitted by Goose <goose@example.org>",
+		"example.org",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	)
+
+	var got []string
+	for _, err := range Parse(in).Warnings {
+		if v, ok := err.(PossiblyMergedEntities); ok {
+			got = append(got, fmt.Sprintf("%s: %s", v.Suffixes.Entity, v.Line.LocationString()))
+		}
 	}
+	want := []string{
+		"DuckCorp Inc: line 17",
+	}
+	checkDiff(t, "possibly merged entities", got, want)
+}
 
-	prevLine := 0
-	var rebuilt bytes.Buffer
-	for _, block := range f.Blocks {
-		src := block.source()
-		if src.lineOffset < prevLine {
-			t.Fatalf("ordering error: previous block ended at %d but this block starts at %d:\n%s", prevLine, src.lineOffset, src.Text())
+func TestDuplicateSuffixes(t *testing.T) {
+	t.Parallel()
+
+	in := byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// example : https://example.com",
+		"example",
+		"bücher.example",
+		"shared.example",
+		"other.example",
+		"",
+		"// ===END ICANN DOMAINS===",
+		"",
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc: https://example.com",
+		"// Submitted by Not A Duck <duck@example.com>",
+		"duck.example",
+		"Duck.example",
+		"xn--bcher-kva.example",
+		"shared.example",
+		"Other.example",
+		"*.pond.example",
+		"*.pond.example",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	)
+
+	var got []string
+	for _, err := range Parse(in).Errors {
+		if v, ok := err.(DuplicateSuffix); ok {
+			got = append(got, fmt.Sprintf("%s duplicates %s", v.Suffix.Text(), v.Previous.Text()))
 		}
-		for prevLine < src.lineOffset {
-			rebuilt.WriteByte('\n')
-			prevLine++
+	}
+	want := []string{
+		"Duck.example duplicates duck.example",
+		"xn--bcher-kva.example duplicates bücher.example",
+		// "shared.example" is reported as a CrossSectionDuplicate.
+		"Other.example duplicates other.example",
+		"*.pond.example duplicates *.pond.example",
+	}
+	checkDiff(t, "duplicate suffixes", got, want)
+}
+
+func TestReservedSuffixes(t *testing.T) {
+	t.Parallel()
+
+	lines := []any{
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc: https://example.com",
+		"// Submitted by Not A Duck <duck@example.com>",
+	}
+	var want []string
+	for _, reserved := range reservedSuffixes {
+		lines = append(lines, reserved, "*.duck."+reserved)
+		want = append(want, reserved+" "+reserved, "*.duck."+reserved+" "+reserved)
+	}
+	lines = append(lines,
+		"LocalHost",
+		"duck.onion",
+		"examples.com",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	)
+	want = append(want, "LocalHost localhost")
+
+	var got []string
+	for _, err := range Parse(byteLines(lines...)).Errors {
+		if v, ok := err.(ReservedSuffix); ok {
+			got = append(got, v.Suffix.Text()+" "+v.Reserved)
 		}
-		rebuilt.WriteString(src.Text())
-		rebuilt.WriteByte('\n')
-		prevLine = src.lineOffset + len(src.lines)
 	}
+	checkDiff(t, "reserved suffixes", got, want)
+}
 
-	got := strings.Split(strings.TrimSpace(rebuilt.String()), "\n")
-	want := strings.Split(strings.TrimSpace(string(bs)), "\n")
+func TestPlaceholderMetadata(t *testing.T) {
+	t.Parallel()
 
-	if diff := diff.Diff(want, got); diff != "" {
-		t.Errorf("roundtrip failed (-want +got):\n%s", diff)
+	in := byteLines(
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// Organization Name : https://duck.com",
+		"// Submitted by Not A Duck <duck@duck.com>",
+		"duck.com",
+		"",
+		"// Goose Gang : https://goose.com",
+		"// Submitted by YOUR NAME <goose@goose.com>",
+		"goose.com",
+		"",
+		"// Swan Sisters : https://swan.com",
+		"// Submitted by Swan <Your-Email@example.com>",
+		"swan.com",
+		"",
+		"// Your Namesake Ltd : https://namesake.com",
+		"// Submitted by Your Name <you@example.com>",
+		"namesake.com",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	)
+
+	var got []string
+	for _, err := range Parse(in).Errors {
+		if v, ok := err.(PlaceholderMetadata); ok {
+			got = append(got, fmt.Sprintf("%s: %s %q", v.Suffixes.Entity, v.Field, v.Placeholder))
+		}
+	}
+	want := []string{
+		`Organization Name: entity name "organization name"`,
+		`Goose Gang: contact name "your name"`,
+		`Swan Sisters: contact email "your-email@example.com"`,
+		// Reported once per block.
+		`Your Namesake Ltd: entity name "your name"`,
 	}
+	checkDiff(t, "placeholder metadata", got, want)
 }
 
-// TestRoundtripRealListDetailed is like the prior round-tripping
-// test, but Suffix blocks are written out using their
-// Header/Entries/InlineComments fields, again as proof that no suffix
-// block elements were lost during parsing.
-func TestRoundtripRealListDetailed(t *testing.T) {
-	bs, err := os.ReadFile("../../../public_suffix_list.dat")
-	if err != nil {
-		t.Fatal(err)
+func TestExceptionsFor(t *testing.T) {
+	t.Parallel()
+
+	f := Parse(byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// ck : https://en.wikipedia.org/wiki/.ck",
+		"*.ck",
+		"!www.ck",
+		"",
+		"// jp : https://en.wikipedia.org/wiki/.jp",
+		"*.kawasaki.jp",
+		"*.kitakyushu.jp",
+		"!city.kawasaki.jp",
+		"!city.kitakyushu.jp",
+		"!www.city.kawasaki.jp",
+		"!zoo.kawasaki.jp",
+		"",
+		"// ===END ICANN DOMAINS===",
+	))
+
+	tests := []struct {
+		base string
+		want []string
+	}{
+		{"ck", []string{"www"}},
+		{"kawasaki.jp", []string{"city", "zoo"}},
+		{"kitakyushu.jp", []string{"city"}},
+		{"jp", nil},
+		{"nope.ck", nil},
 	}
-	f := Parse(bs)
+	for _, tc := range tests {
+		checkDiff(t, fmt.Sprintf("ExceptionsFor(%q)", tc.base), f.ExceptionsFor(tc.base), tc.want)
+	}
+}
 
-	if len(f.Errors) > 0 {
-		t.Fatal("Parse errors, not attempting to roundtrip")
+func TestFreeEmailMaintainers(t *testing.T) {
+	// Not parallel, modifies freeEmailMaintainerEntities.
+
+	in := byteLines(
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc : https://duck.com",
+		"// Submitted by Not A Duck <not.a.duck@GMail.com>",
+		"duck.com",
+		"duck.net",
+		"",
+		"// Goose Gang : https://goose.com",
+		"// Submitted by Very Much A Goose <goose@gmail.com>",
+		"goose.com",
+		"",
+		"// Swan Sisters : https://swan.com",
+		"// Submitted by Swan <swan@swan.com>",
+		"swan.com",
+		"swan.net",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	)
+	entities := func() []string {
+		var ret []string
+		for _, err := range Parse(in).Warnings {
+			if v, ok := err.(FreeEmailMaintainer); ok {
+				ret = append(ret, v.Suffixes.Entity)
+			}
+		}
+		return ret
 	}
 
-	prevLine := 0
-	var rebuilt bytes.Buffer
-	for _, block := range f.Blocks {
-		srcs := []Source{block.source()}
-		if v, ok := block.(Suffixes); ok {
-			srcs = []Source{}
-			for _, h := range v.Header {
-				srcs = append(srcs, h)
+	// Goose Gang only has one suffix.
+	checkDiff(t, "free email maintainers", entities(), []string{"DuckCorp Inc"})
+
+	old := freeEmailMaintainerEntities
+	defer func() { freeEmailMaintainerEntities = old }()
+	freeEmailMaintainerEntities = []string{"DuckCorp Inc"}
+	checkDiff(t, "free email maintainers with exemption", entities(), []string(nil))
+}
+
+func TestEmptyLabels(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		suffix string
+		want   string // error message, or empty for no error
+	}{
+		{"duck.com", ""},
+		{"*.duck.com", ""},
+		{"duck..com", `suffix "duck..com" at line 5 has consecutive dots`},
+		{".duck.com", `suffix ".duck.com" at line 5 has a leading dot`},
+		{"duck.com.", `suffix "duck.com." at line 5 has a trailing dot`},
+		{"*..duck.com", `suffix "*..duck.com" at line 5 has a leading dot`},
+		{"*.duck..com", `suffix "*.duck..com" at line 5 has consecutive dots`},
+		{"*.", `suffix "*." at line 5 has an empty label`},
+		{".", `suffix "." at line 5 has a leading dot`},
+		// Exceptions are reported as an EmptyWildcardException.
+		{"!a..duck.com", ""},
+	}
+
+	for _, tc := range tests {
+		block := Parse(byteLines(
+			"// ===BEGIN PRIVATE DOMAINS===",
+			"",
+			"// DuckCorp Inc : https://duck.com",
+			"// Submitted by Not A Duck <duck@duck.com>",
+			tc.suffix,
+			"",
+			"// ===END PRIVATE DOMAINS===",
+		)).AllSuffixBlocks()[0]
+		errs, _ := ValidateSuffixes(block, "PRIVATE DOMAINS", Options{})
+
+		var got string
+		for _, err := range errs {
+			if e, ok := err.(EmptyLabel); ok {
+				got = e.Error()
 			}
-			for _, e := range v.Entries {
-				srcs = append(srcs, e)
+		}
+		if got != tc.want {
+			t.Errorf("empty label error for %q is %q, want %q", tc.suffix, got, tc.want)
+		}
+	}
+}
+
+func TestMalformedEntityHeaders(t *testing.T) {
+	t.Parallel()
+
+	in := byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// com : https://www.iana.org/domains/root/db/com.html",
+		"// ICANN headers are not checked :-)",
+		"com",
+		"",
+		"// ===END ICANN DOMAINS===",
+		"",
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc (https://duck.com",
+		"// Submitted by Not A Duck <duck@duck.com>",
+		"duck.com",
+		"",
+		"// Goose Gang : https://goose.com",
+		"// Submitted by Very Much A Goose <goose@goose.com>)",
+		"goose.com",
+		"",
+		"// Swan Sisters : https://swan.com",
+		"// Submitted by Swan [Sisters <swan@swan.com>]",
+		"// Swan (the bird [not the river] <swan@swan.com>)",
+		"swan.com",
+		"",
+		"// Owl Inc : https://owl.com",
+		"// Submitted by Owl (the [bird) <owl@owl.com>]",
+		"owl.com",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	)
+
+	var got []string
+	for _, err := range Parse(in).Errors {
+		if v, ok := err.(MalformedEntityHeader); ok {
+			got = append(got, fmt.Sprintf("%s %q", v.Line.LocationString(), v.Bracket))
+		}
+	}
+	want := []string{
+		`line 11 '('`,
+		`line 16 ')'`,
+		`line 25 ')'`,
+	}
+	checkDiff(t, "malformed headers", got, want)
+}
+
+func TestSuffixesCoveredByWildcard(t *testing.T) {
+	t.Parallel()
+
+	in := byteLines(
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc : https://duck.com",
+		"// Submitted by Not A Duck <duck@duck.com>",
+		"*.pond.duck.com",
+		"!www.pond.duck.com",
+		"a.pond.duck.com",
+		"www.pond.duck.com",
+		"b.a.pond.duck.com",
+		"*.lake.duck.com",
+		"",
+		"// Goose Gang : https://goose.com",
+		"// Submitted by Very Much A Goose <goose@goose.com>",
+		"c.pond.duck.com",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	)
+
+	var got []string
+	for _, err := range Parse(in).Errors {
+		if v, ok := err.(SuffixCoveredByWildcard); ok {
+			got = append(got, v.Suffix.Text()+" "+v.Wildcard.Text())
+		}
+	}
+	want := []string{
+		// www.pond.duck.com is excepted from the wildcard,
+		// b.a.pond.duck.com is two labels below it, and
+		// c.pond.duck.com is listed by a different entity.
+		"a.pond.duck.com *.pond.duck.com",
+	}
+	checkDiff(t, "suffixes covered by wildcards", got, want)
+}
+
+func TestEmptyEntities(t *testing.T) {
+	t.Parallel()
+
+	in := byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// com : https://www.iana.org/domains/root/db/com.html",
+		"",
+		"com",
+		"",
+		"// ===END ICANN DOMAINS===",
+		"",
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// This is just a comment, not a header",
+		"",
+		"// DuckCorp Inc : https://duck.com",
+		"// Submitted by Not A Duck <duck@duck.com>",
+		"",
+		"// Goose Gang",
+		"// Submitted by Very Much A Goose <goose@goose.com>",
+		"",
+		"// Swan Sisters : https://swan.com",
+		"// Submitted by Swan <swan@swan.com>",
+		"swan.com",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	)
+
+	var got []string
+	for _, err := range Parse(in).Errors {
+		if v, ok := err.(EmptyEntity); ok {
+			got = append(got, v.Comment.LocationString())
+		}
+	}
+	want := []string{
+		// The ICANN comment at line 3 is not checked.
+		"lines 13-14",
+		"lines 16-17",
+	}
+	checkDiff(t, "empty entities", got, want)
+}
+
+// FuzzParse checks that Parse and ValidateSuffixes never panic, and
+// that the parse result is structurally sound: blocks are in file
+// order and don't overlap, and every block of suffixes has at least
+// one suffix.
+func FuzzParse(f *testing.F) {
+	f.Add([]byte(""))
+	f.Add(byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// com : https://www.iana.org/domains/root/db/com.html",
+		"com",
+		"*.ck",
+		"!www.ck",
+		"",
+		"// ===END ICANN DOMAINS===",
+		"",
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc : https://example.com",
+		"// Submitted by Not A Duck <duck@example.com>",
+		"example.com",
+		"// Pond suffixes",
+		"*.pond.example.com",
+		"!xn--bcher-kva.pond.example.com",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+		"",
+	))
+	f.Add(byteLines(
+		"// ===BEGIN PRIVATE DOMAINS",
+		"// ===BEGIN ICANN DOMAINS===",
+		"\t !..",
+		"*.",
+		"// (<[",
+		"\r",
+	))
+	f.Add([]byte("\xff\xfe/\x00/\x00"))
+
+	f.Fuzz(func(t *testing.T, bs []byte) {
+		got := Parse(bs)
+		if got == nil {
+			t.Fatal("Parse returned nil")
+		}
+
+		prevEnd := 0
+		for _, block := range got.Blocks {
+			src := block.source()
+			if src.lineOffset < prevEnd {
+				t.Fatalf("block at line %d overlaps the previous block, which ends at line %d", src.lineOffset+1, prevEnd)
 			}
-			for _, c := range v.InlineComments {
-				srcs = append(srcs, c)
+			prevEnd = src.lineOffset + len(src.lines)
+
+			if s, ok := block.(Suffixes); ok && len(s.Entries) == 0 {
+				t.Fatalf("block of suffixes at %s has no suffixes", s.LocationString())
 			}
-			slices.SortFunc(srcs, func(a, b Source) int {
-				return cmp.Compare(a.lineOffset, b.lineOffset)
-			})
 		}
 
-		for _, src := range srcs {
-			if src.lineOffset < prevLine {
-				t.Fatalf("ordering error: previous block ended at %d but this block starts at %d:\n%s", prevLine, src.lineOffset, src.Text())
-			}
-			for prevLine < src.lineOffset {
-				rebuilt.WriteByte('\n')
-				prevLine++
+		// Validation only runs on input without parse errors, which
+		// random input rarely is. Run the single-block validations
+		// on every block anyway, since malformed submissions reach
+		// them one block at a time.
+		errs := append(got.Errors, got.Warnings...)
+		for _, block := range got.AllSuffixBlocks() {
+			blockErrs, blockWarnings := ValidateSuffixes(block, "PRIVATE DOMAINS", Options{})
+			errs = append(errs, blockErrs...)
+			errs = append(errs, blockWarnings...)
+		}
+		for _, err := range errs {
+			if err.Error() == "" {
+				t.Fatalf("%T has an empty error message", err)
 			}
-			rebuilt.WriteString(src.Text())
-			rebuilt.WriteByte('\n')
-			prevLine = src.lineOffset + len(src.lines)
 		}
+	})
+}
+
+func TestInvalidHyphenation(t *testing.T) {
+	t.Parallel()
+
+	in := byteLines(
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc : https://duck.com",
+		"// Submitted by Not A Duck <duck@duck.com>",
+		"ab--cd.duck.com",
+		"a--b.duck.com",
+		"abc--d.duck.com",
+		"*.pond.xx--duck.com",
+		"bü--ck.duck.com",
+		"xn--bcher-kva.duck.com",
+		"XN--BCHER-KVA.pond.duck.com",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	)
+
+	// Hyphens in other positions, and valid punycode labels, are
+	// fine.
+	var got []string
+	for _, err := range Parse(in).Errors {
+		if v, ok := err.(InvalidHyphenation); ok {
+			got = append(got, v.Suffix.Text()+" "+v.Label)
+		}
+	}
+	want := []string{
+		"ab--cd.duck.com ab--cd",
+		"*.pond.xx--duck.com xx--duck",
+		"bü--ck.duck.com bü--ck",
 	}
+	checkDiff(t, "invalid hyphenation", got, want)
+}
 
-	got := strings.Split(strings.TrimSpace(rebuilt.String()), "\n")
-	want := strings.Split(strings.TrimSpace(string(bs)), "\n")
+func TestUnsortedExceptions(t *testing.T) {
+	t.Parallel()
 
-	if diff := diff.Diff(want, got); diff != "" {
-		t.Errorf("roundtrip failed (-want +got):\n%s", diff)
+	in := byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// com : https://en.wikipedia.org/wiki/.com",
+		"com",
+		"",
+		"// ck : https://en.wikipedia.org/wiki/.ck",
+		"*.ck",
+		"!www.ck",
+		"",
+		"// jp : https://en.wikipedia.org/wiki/.jp",
+		"*.kawasaki.jp",
+		"!city.kawasaki.jp",
+		"!town.kawasaki.jp",
+		"*.kobe.jp",
+		"!town.kobe.jp",
+		"!city.kobe.jp",
+		"!ward.kobe.jp",
+		"",
+		"// ===END ICANN DOMAINS===",
+		"",
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc : https://duck.com",
+		"// Submitted by Not A Duck <duck@duck.com>",
+		"*.pond.duck.com",
+		"!b.pond.duck.com",
+		"!a.pond.duck.com",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	)
+
+	// Single exceptions and sorted exceptions are fine. Unsorted
+	// exceptions in the private section are reported as unsorted
+	// suffixes instead.
+	var got []string
+	for _, err := range Parse(in).Warnings {
+		if v, ok := err.(UnsortedExceptions); ok {
+			got = append(got, v.Wildcard.Text()+" "+v.Exception.Text()+" "+v.Before.Text())
+		}
+	}
+	want := []string{
+		"*.kobe.jp !city.kobe.jp !town.kobe.jp",
 	}
+	checkDiff(t, "unsorted exceptions", got, want)
 }
 
-// TestExceptionsStillNecessary checks that all the exceptions in
-// exeptions.go are still needed to parse the PSL without errors.
-func TestExceptionsStillNecessary(t *testing.T) {
-	bs, err := os.ReadFile("../../../public_suffix_list.dat")
+func TestGenericEntityNames(t *testing.T) {
+	t.Parallel()
+
+	in := byteLines(
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// TEST : https://duck.com",
+		"// Submitted by Not A Duck <duck@duck.com>",
+		"duck.com",
+		"",
+		"// My Company : https://goose.com",
+		"// Submitted by Not A Goose <goose@goose.com>",
+		"goose.com",
+		"",
+		"// Testing Ducks Ltd : https://swan.com",
+		"// Submitted by Not A Swan <swan@swan.com>",
+		"swan.com",
+		"",
+		"// personal website : https://heron.com",
+		"// Submitted by Not A Heron <heron@heron.com>",
+		"heron.com",
+		"",
+		"// Honk Ltd : https://honk.com",
+		"// Submitted by Not A Honk <honk@honk.com>",
+		"honk.com",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	)
+	entities := func(opts Options) []string {
+		var ret []string
+		for _, err := range ParseWithOptions(in, "", opts).Warnings {
+			if v, ok := err.(GenericEntityName); ok {
+				ret = append(ret, v.Suffixes.Entity)
+			}
+		}
+		return ret
+	}
+
+	// Names that only contain a generic word are fine.
+	checkDiff(t, "generic entity names", entities(Options{}), []string{"TEST", "My Company", "personal website"})
+
+	custom, err := CompileGenericEntityNames([]string{`honk( ltd)?`})
 	if err != nil {
 		t.Fatal(err)
 	}
+	checkDiff(t, "generic entity names with custom patterns", entities(Options{GenericEntityNames: custom}), []string{"Honk Ltd"})
+	checkDiff(t, "generic entity names with no patterns", entities(Options{GenericEntityNames: []*regexp.Regexp{}}), []string(nil))
 
-	forEachOmitted(missingEmail, func(omitted string, trimmed []string) {
-		old := missingEmail
-		defer func() { missingEmail = old }()
-		missingEmail = trimmed
-
-		f := Parse(bs)
-		if len(f.Errors) == 0 {
-			t.Errorf("missingEmail exception no longer necessary:\n%s", omitted)
-		}
-	})
+	if _, err := CompileGenericEntityNames([]string{`honk(`}); err == nil {
+		t.Error("CompileGenericEntityNames accepted an invalid pattern")
+	}
 }
 
-func forEachOmitted(exceptions []string, fn func(string, []string)) {
-	for i := range exceptions {
-		next := append([]string(nil), exceptions[:i]...)
-		next = append(next, exceptions[i+1:]...)
-		fn(exceptions[i], next)
+func TestCommentDomainMismatches(t *testing.T) {
+	t.Parallel()
+
+	in := byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// com : https://en.wikipedia.org/wiki/.com",
+		"com",
+		"",
+		"// fi : https://en.wikipedia.org/wiki/.fi",
+		"fi",
+		"",
+		"// net : https://en.wikipedia.org/wiki/.net",
+		"net",
+		"",
+		"// org : https://en.wikipedia.org/wiki/.org",
+		"org",
+		"",
+		"// ===END ICANN DOMAINS===",
+		"",
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc : https://duck.com",
+		"// Submitted by Not A Duck <duck@ducks.org>",
+		"// Formerly also goose.net and Swan.org, see https://tracker.example.org/ducks",
+		"// Version 1.2 of the setup, for customers of ducks.org and duck.com",
+		"pond.duck.com",
+		"// lake.duck.com customers, and goose.net again",
+		"lake.duck.com",
+		"*.river.duck.com",
+		"",
+		"// Häkkinen.fi : https://hakkinen.fi",
+		"// Submitted by Not A Duck <duck@duck.com>",
+		"// Run by Häkkinen.fi for sub.häkkinen.fi",
+		"häkkinen.fi",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+		"",
+	)
+
+	// Domains related to the suffixes, entity name, URL or contact
+	// email are fine, and so are URLs and things that only look like
+	// domains.
+	var got [][]string
+	for _, err := range Parse(in).Warnings {
+		if v, ok := err.(CommentDomainMismatch); ok {
+			got = append(got, v.Domains)
+		}
+	}
+	want := [][]string{
+		{"goose.net", "swan.org"},
 	}
+	checkDiff(t, "comment domain mismatches", got, want)
 }
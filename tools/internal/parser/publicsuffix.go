@@ -0,0 +1,110 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PublicSuffix returns the public suffix of domain according to the
+// rules in f, and whether that suffix comes from the ICANN domains
+// section of f.
+//
+// The lookup follows the algorithm described at
+// https://publicsuffix.org/list/, like golang.org/x/net/publicsuffix,
+// but using the rules in f instead of a built-in copy of the PSL:
+// exception rules take precedence over all other rules, then the
+// matching rule with the most labels wins. If no rule matches, the
+// TLD of domain is its public suffix, and icann is false.
+//
+// domain may be given in either Unicode or punycode form. The returned
+// suffix is in Unicode form, like the entries of the PSL.
+func PublicSuffix(f *File, domain string) (suffix string, icann bool, err error) {
+	name, err := lookupName(domain)
+	if err != nil {
+		return "", false, err
+	}
+
+	suffix, r, ok := matchRule(f, name)
+	if !ok {
+		return tld(name), false, nil
+	}
+	return suffix, r.icann, nil
+}
+
+// LookupEntity returns the entity name of the block that contains the
+// rule that determines the public suffix of domain in f (see
+// PublicSuffix), and the text of that rule. When an exception rule
+// matches, such as "!www.ck" for "www.ck", the exception and its
+// block are returned, since exceptions are maintained by the owner of
+// the wildcard they carve out of.
+//
+// If no rule matches domain, LookupEntity returns empty strings and a
+// nil error. An error is only returned if domain is not a valid
+// domain name.
+func LookupEntity(f *File, domain string) (entity, rule string, err error) {
+	name, err := lookupName(domain)
+	if err != nil {
+		return "", "", err
+	}
+
+	_, r, ok := matchRule(f, name)
+	if !ok {
+		return "", "", nil
+	}
+	return r.block.Entity, r.entry.Text(), nil
+}
+
+// lookupName returns domain in the form used to match PSL rules:
+// lowercase and Unicode.
+func lookupName(domain string) (string, error) {
+	name, err := pslIDNA.ToUnicode(strings.ToLower(domain))
+	if err != nil || !isHostname(name) {
+		return "", fmt.Errorf("invalid domain name %q", domain)
+	}
+	return name, nil
+}
+
+// pslRule is a rule of a PSL file, and where it comes from.
+type pslRule struct {
+	icann bool
+	block Suffixes
+	entry Source
+}
+
+// matchRule returns the public suffix of name according to the rules
+// in f, and the rule that determined it. ok is false if no rule
+// matches. See PublicSuffix for the matching algorithm.
+func matchRule(f *File, name string) (suffix string, r pslRule, ok bool) {
+	rules := map[string]pslRule{}
+	for _, block := range f.SuffixBlocksInSection("ICANN DOMAINS") {
+		for _, entry := range block.Entries {
+			rules[entry.Text()] = pslRule{true, block, entry}
+		}
+	}
+	for _, block := range f.SuffixBlocksInSection("PRIVATE DOMAINS") {
+		for _, entry := range block.Entries {
+			if _, ok := rules[entry.Text()]; !ok {
+				rules[entry.Text()] = pslRule{false, block, entry}
+			}
+		}
+	}
+
+	// Walk from the full domain towards the TLD, so that the first
+	// match found is the one with the most labels.
+	for cur := name; cur != ""; cur = parentDomain(cur) {
+		if r, ok := rules["!"+cur]; ok {
+			return parentDomain(cur), r, true
+		}
+	}
+	for cur := name; cur != ""; cur = parentDomain(cur) {
+		if r, ok := rules[cur]; ok {
+			return cur, r, true
+		}
+		if parent := parentDomain(cur); parent != "" {
+			if r, ok := rules["*."+parent]; ok {
+				return cur, r, true
+			}
+		}
+	}
+	return "", pslRule{}, false
+}
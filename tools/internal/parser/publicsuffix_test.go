@@ -0,0 +1,118 @@
+package parser
+
+import "testing"
+
+func TestPublicSuffix(t *testing.T) {
+	t.Parallel()
+
+	f := Parse(byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"com",
+		"",
+		"jp",
+		"*.kawasaki.jp",
+		"!city.kawasaki.jp",
+		"",
+		"cn",
+		"公司.cn",
+		"",
+		"// ===END ICANN DOMAINS===",
+		"",
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// Blogspot : https://www.blogger.com",
+		"// Submitted by Not A Duck <duck@blogger.com>",
+		"blogspot.com",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	))
+
+	tests := []struct {
+		domain    string
+		want      string
+		wantICANN bool
+		wantErr   bool
+	}{
+		{domain: "com", want: "com", wantICANN: true},
+		{domain: "example.com", want: "com", wantICANN: true},
+		{domain: "www.EXAMPLE.com", want: "com", wantICANN: true},
+		{domain: "blogspot.com", want: "blogspot.com"},
+		{domain: "duck.blogspot.com", want: "blogspot.com"},
+		{domain: "kawasaki.jp", want: "jp", wantICANN: true},
+		{domain: "foo.kawasaki.jp", want: "foo.kawasaki.jp", wantICANN: true},
+		{domain: "www.foo.kawasaki.jp", want: "foo.kawasaki.jp", wantICANN: true},
+		{domain: "city.kawasaki.jp", want: "kawasaki.jp", wantICANN: true},
+		{domain: "www.city.kawasaki.jp", want: "kawasaki.jp", wantICANN: true},
+		{domain: "example.公司.cn", want: "公司.cn", wantICANN: true},
+		{domain: "example.xn--55qx5d.cn", want: "公司.cn", wantICANN: true},
+		{domain: "example.unknown", want: "unknown"},
+		{domain: "", wantErr: true},
+		{domain: "example..com", wantErr: true},
+		{domain: "under_score.com", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		got, gotICANN, err := PublicSuffix(f, tc.domain)
+		if gotErr := err != nil; gotErr != tc.wantErr {
+			t.Errorf("PublicSuffix(%q) got err=%v, want err=%v", tc.domain, err, tc.wantErr)
+			continue
+		}
+		if got != tc.want || gotICANN != tc.wantICANN {
+			t.Errorf("PublicSuffix(%q) = %q, %v, want %q, %v", tc.domain, got, gotICANN, tc.want, tc.wantICANN)
+		}
+	}
+}
+
+func TestLookupEntity(t *testing.T) {
+	t.Parallel()
+
+	f := Parse(byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// com : https://www.iana.org/domains/root/db/com.html",
+		"com",
+		"",
+		"// jp : https://en.wikipedia.org/wiki/.jp",
+		"jp",
+		"*.kawasaki.jp",
+		"!city.kawasaki.jp",
+		"",
+		"// ===END ICANN DOMAINS===",
+		"",
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// Blogspot : https://www.blogger.com",
+		"// Submitted by Not A Duck <duck@blogger.com>",
+		"blogspot.com",
+		"*.pages.blogspot.com",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	))
+
+	tests := []struct {
+		domain     string
+		wantEntity string
+		wantRule   string
+		wantErr    bool
+	}{
+		{domain: "example.com", wantEntity: "com", wantRule: "com"},
+		{domain: "duck.blogspot.com", wantEntity: "Blogspot", wantRule: "blogspot.com"},
+		{domain: "www.duck.pages.blogspot.com", wantEntity: "Blogspot", wantRule: "*.pages.blogspot.com"},
+		{domain: "foo.kawasaki.jp", wantEntity: "jp", wantRule: "*.kawasaki.jp"},
+		{domain: "www.city.kawasaki.jp", wantEntity: "jp", wantRule: "!city.kawasaki.jp"},
+		{domain: "example.unknown"},
+		{domain: "example..com", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		entity, rule, err := LookupEntity(f, tc.domain)
+		if gotErr := err != nil; gotErr != tc.wantErr {
+			t.Errorf("LookupEntity(%q) got err=%v, want err=%v", tc.domain, err, tc.wantErr)
+			continue
+		}
+		if entity != tc.wantEntity || rule != tc.wantRule {
+			t.Errorf("LookupEntity(%q) = %q, %q, want %q, %q", tc.domain, entity, rule, tc.wantEntity, tc.wantRule)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrorInfo is a machine-readable description of a parse or
+// validation error, suitable for JSON encoding.
+type ErrorInfo struct {
+	// Type identifies the kind of error, for example
+	// "missing_entity_name". It is the value of the error's Code
+	// method.
+	Type string `json:"type"`
+	// Message is the human-readable error message.
+	Message string `json:"message"`
+	// Path is the path of the PSL file.
+	Path string `json:"path,omitempty"`
+	// StartLine and EndLine are the 1-indexed, inclusive range of
+	// lines that the error is about. Both are zero for errors that
+	// apply to the whole file.
+	StartLine int `json:"start_line,omitempty"`
+	EndLine   int `json:"end_line,omitempty"`
+	// StartByte and EndByte are the byte offsets in the PSL file of
+	// the text that the error is about, in the half-open form
+	// [StartByte:EndByte). Leading and trailing whitespace of lines
+	// is not included, except for errors about that whitespace. Both
+	// are zero if the offsets are unknown, including for files that
+	// are not UTF-8.
+	StartByte int `json:"start_byte,omitempty"`
+	EndByte   int `json:"end_byte,omitempty"`
+	// Suffix is the suffix that the error is about, for errors that
+	// concern a single suffix.
+	Suffix string `json:"suffix,omitempty"`
+}
+
+// Describe returns an ErrorInfo for each error in errs, which were
+// produced by parsing the PSL file at path. If path is empty, the path
+// recorded by ParseReader is used, if any.
+func Describe(path string, errs []error) []ErrorInfo {
+	ret := make([]ErrorInfo, 0, len(errs))
+	for _, err := range errs {
+		info := ErrorInfo{
+			Type:    errorType(err),
+			Message: err.Error(),
+			Path:    path,
+		}
+		if e, ok := err.(interface{ location() Source }); ok {
+			info.StartLine, info.EndLine = e.location().lineRange()
+			if info.Path == "" {
+				info.Path = e.location().path
+			}
+			if start, end, ok := e.location().byteRange(); ok {
+				info.StartByte, info.EndByte = start, end
+			}
+		}
+		if e, ok := err.(interface{ suffix() Source }); ok {
+			info.Suffix = e.suffix().Text()
+		}
+		ret = append(ret, info)
+	}
+	return ret
+}
+
+// ErrorGroup is the errors located in one block of suffixes. See
+// GroupByBlock.
+type ErrorGroup struct {
+	// Suffixes is the block that the errors are located in. It is the
+	// zero Suffixes for the group of errors that aren't located in any
+	// block, such as errors about the structure of the whole file.
+	Suffixes Suffixes
+	// Errors are the errors located in Suffixes, in their original
+	// order.
+	Errors []error
+}
+
+// GroupByBlock groups errs, which were produced by parsing f, by the
+// block of suffixes that each error is located in. Groups are in file
+// order, followed by a group of the errors that aren't located in any
+// block, if there are any. An error that spans several blocks belongs
+// to the first of them.
+func GroupByBlock(f *File, errs []error) []ErrorGroup {
+	blocks := f.AllSuffixBlocks()
+	groups := make([][]error, len(blocks)+1)
+	for _, err := range errs {
+		i := len(blocks)
+		if e, ok := err.(interface{ location() Source }); ok {
+			for j := range blocks {
+				if overlapsAny(e.location(), blocks[j:j+1]) {
+					i = j
+					break
+				}
+			}
+		}
+		groups[i] = append(groups[i], err)
+	}
+
+	var ret []ErrorGroup
+	for i, errs := range groups {
+		if len(errs) == 0 {
+			continue
+		}
+		g := ErrorGroup{Errors: errs}
+		if i < len(blocks) {
+			g.Suffixes = blocks[i]
+		}
+		ret = append(ret, g)
+	}
+	return ret
+}
+
+// Heading returns a one-line description of g, for example
+// `"DuckCorp Inc" at lines 12-15: 3 issues`.
+func (g ErrorGroup) Heading() string {
+	issues := fmt.Sprintf("%d issues", len(g.Errors))
+	if len(g.Errors) == 1 {
+		issues = "1 issue"
+	}
+	if len(g.Suffixes.lines) == 0 {
+		return "Other: " + issues
+	}
+	return fmt.Sprintf("%s at %s: %s", g.Suffixes.shortName(), g.Suffixes.LocationString(), issues)
+}
+
+// GitHubAnnotation returns e formatted as a GitHub Actions workflow
+// command, which makes GitHub display e as an annotation on the
+// relevant file and lines. level is the kind of annotation, either
+// "error" or "warning".
+//
+// See
+// https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions
+// for details of the format.
+func (e ErrorInfo) GitHubAnnotation(level string) string {
+	var params []string
+	if e.Path != "" {
+		params = append(params, "file="+escapeAnnotationProperty(e.Path))
+	}
+	if e.StartLine > 0 {
+		params = append(params, fmt.Sprintf("line=%d", e.StartLine))
+		if e.EndLine > e.StartLine {
+			params = append(params, fmt.Sprintf("endLine=%d", e.EndLine))
+		}
+	}
+	params = append(params, "title="+escapeAnnotationProperty(e.Type))
+
+	return fmt.Sprintf("::%s %s::%s", level, strings.Join(params, ","), escapeAnnotationData(e.Message))
+}
+
+var (
+	annotationDataEscaper     = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	annotationPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+func escapeAnnotationData(s string) string     { return annotationDataEscaper.Replace(s) }
+func escapeAnnotationProperty(s string) string { return annotationPropertyEscaper.Replace(s) }
+
+// punycodeDomain matches domain names and suffix entries with at
+// least one punycode label, optionally in double quotes. The first
+// submatch is the domain, without the preceding character that marks
+// the start of its first label, so that an "xn--" in the middle of a
+// label is not mistaken for the start of a punycode label.
+var punycodeDomain = regexp.MustCompile(`(?:^|[^a-zA-Z0-9.-])("?(?:\*\.|!)?(?:[a-zA-Z0-9-]+\.)*[xX][nN]--[a-zA-Z0-9-]*(?:\.[a-zA-Z0-9-]+)*"?)`)
+
+// WithUnicodeDomains returns msg with the Unicode form of every domain
+// name that contains punycode labels added after it, for example
+// "xn--e1afmkfd.xn--p1ai (пример.рф)". Domains that don't decode to a
+// valid Unicode form are left alone.
+//
+// This makes messages about internationalized suffixes easier to read
+// for humans, but the result is no longer ASCII, so it should not be
+// used for machine-readable output.
+func WithUnicodeDomains(msg string) string {
+	var ret strings.Builder
+	last := 0
+	for _, m := range punycodeDomain.FindAllStringSubmatchIndex(msg, -1) {
+		start, end := m[2], m[3]
+		ret.WriteString(msg[last:end])
+		last = end
+
+		domain := strings.Trim(msg[start:end], `"`)
+		prefix := ""
+		if strings.HasPrefix(domain, "!") {
+			prefix, domain = "!", domain[1:]
+		} else if strings.HasPrefix(domain, "*.") {
+			prefix, domain = "*.", domain[2:]
+		}
+		u, err := pslIDNA.ToUnicode(domain)
+		if err != nil || u == strings.ToLower(domain) {
+			continue
+		}
+		fmt.Fprintf(&ret, " (%s%s)", prefix, u)
+	}
+	ret.WriteString(msg[last:])
+	return ret.String()
+}
+
+// errorType returns the Code of err, or "error" for errors that
+// don't come from this package.
+func errorType(err error) string {
+	if e, ok := err.(interface{ Code() string }); ok {
+		return e.Code()
+	}
+	return "error"
+}
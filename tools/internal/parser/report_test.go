@@ -0,0 +1,369 @@
+package parser
+
+import (
+	"errors"
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestDescribe(t *testing.T) {
+	t.Parallel()
+
+	suffixes := Suffixes{
+		Source: mkSrc(3, "// DuckCorp Inc: https://example.com", "example.com"),
+		Header: []Source{
+			mkSrc(3, "// DuckCorp Inc: https://example.com"),
+		},
+		Entries: []Source{
+			mkSrc(4, "example.com"),
+		},
+		Entity: "DuckCorp Inc",
+		URL:    mustURL("https://example.com"),
+	}
+
+	errs := []error{
+		UTF8BOMError{},
+		TrailingWhitespaceError{
+			Line: mkSrc(1, "example.org"),
+		},
+		MissingEntityEmail{
+			Suffixes: suffixes,
+		},
+		UnsortedSuffixes{
+			Suffixes: suffixes,
+			Suffix:   mkSrc(4, "example.com"),
+			Before:   mkSrc(3, "example.org"),
+		},
+		errors.New("some other error"),
+	}
+	want := []ErrorInfo{
+		{
+			Type:    "utf8_bom",
+			Message: UTF8BOMError{}.Error(),
+			Path:    "psl.dat",
+		},
+		{
+			Type:      "trailing_whitespace",
+			Message:   errs[1].Error(),
+			Path:      "psl.dat",
+			StartLine: 2,
+			EndLine:   2,
+		},
+		{
+			Type:      "missing_entity_email",
+			Message:   errs[2].Error(),
+			Path:      "psl.dat",
+			StartLine: 4,
+			EndLine:   5,
+		},
+		{
+			Type:      "unsorted_suffixes",
+			Message:   errs[3].Error(),
+			Path:      "psl.dat",
+			StartLine: 5,
+			EndLine:   5,
+			Suffix:    "example.com",
+		},
+		{
+			Type:    "error",
+			Message: "some other error",
+			Path:    "psl.dat",
+		},
+	}
+
+	got := Describe("psl.dat", errs)
+	checkDiff(t, "Describe output", got, want)
+}
+
+func TestDescribeByteOffsets(t *testing.T) {
+	t.Parallel()
+
+	in := byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// Bücher : https://example.com",
+		"bücher",
+		"Bücher.bücher",
+		"",
+		"// ===END ICANN DOMAINS===",
+	)
+	infos := Describe("", Parse(in).Errors)
+	if len(infos) != 1 {
+		t.Fatalf("got %d errors, want 1: %v", len(infos), infos)
+	}
+	info := infos[0]
+	if got := string(in[info.StartByte:info.EndByte]); got != "Bücher.bücher" {
+		t.Errorf("error offsets %d-%d select %q, want %q", info.StartByte, info.EndByte, got, "Bücher.bücher")
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	t.Parallel()
+
+	allErrors := []interface {
+		error
+		Code() string
+		Severity() Severity
+	}{
+		InvalidEncodingError{},
+		UTF8BOMError{},
+		InvalidUTF8Error{},
+		DOSNewlineError{},
+		TrailingWhitespaceError{},
+		TrailingNewlineError{},
+		LeadingWhitespaceError{},
+		TabCharacterError{},
+		LongLineError{},
+		SectionInSuffixBlock{},
+		UnclosedSectionError{},
+		NestedSectionError{},
+		UnstartedSectionError{},
+		MismatchedSectionError{},
+		UnknownSectionMarker{},
+		UnknownSectionName{},
+		DuplicateSection{},
+		UnterminatedSectionMarker{},
+		MissingEntityName{},
+		MissingEntityEmail{},
+		InvalidEntityEmail{},
+		MalformedEntityHeader{},
+		PlaceholderMetadata{},
+		GenericEntityName{},
+		CommentDomainMismatch{},
+		FreeEmailMaintainer{},
+		InvalidEntityURL{},
+		InsecureEntityURL{},
+		RedundantSuffix{},
+		NonCanonicalSuffix{},
+		UnsortedSuffixes{},
+		UnsortedExceptions{},
+		UnknownTLD{},
+		CrossSectionDuplicate{},
+		DuplicateEntityName{},
+		UnrelatedMaintainerEmail{},
+		InvalidWildcardException{},
+		InvalidSuffixLabel{},
+		EmptyLabel{},
+		SuffixWildcardOverlap{},
+		SuffixCoveredByWildcard{},
+		DuplicateMaintainer{},
+		SuffixTooLong{},
+		LabelTooLong{},
+		ICANNSectionModified{},
+		ICANNSuffixRemoved{},
+		SuffixParentAlreadySuffix{},
+		DanglingException{},
+		BlockedSuffix{},
+		InvalidPunycode{},
+		InvalidHyphenation{},
+		TLDInPrivateSection{},
+		InvalidEntityName{},
+		OrphanedException{},
+		SuffixInWrongSection{},
+		InvalidEmailTLD{},
+		SharedMaintainer{},
+		MissingSubmissionReference{},
+		EmptyWildcardException{},
+		PossiblyMergedEntities{},
+		EmptyEntity{},
+		DuplicateSuffix{},
+		ReservedSuffix{},
+	}
+
+	// Check that allErrors is complete, by finding all the types in
+	// errors.go that have an Error method.
+	fset := token.NewFileSet()
+	f, err := goparser.ParseFile(fset, "errors.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var declared []string
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv == nil || fn.Name.Name != "Error" {
+			continue
+		}
+		declared = append(declared, fn.Recv.List[0].Type.(*ast.Ident).Name)
+	}
+	var listed []string
+	for _, e := range allErrors {
+		listed = append(listed, reflect.TypeOf(e).Name())
+	}
+	slices.Sort(declared)
+	slices.Sort(listed)
+	checkDiff(t, "error types in allErrors", listed, declared)
+
+	seen := map[string]string{}
+	for _, e := range allErrors {
+		name := reflect.TypeOf(e).Name()
+		code := e.Code()
+		if code == "" {
+			t.Errorf("%s has an empty code", name)
+		}
+		if prev, ok := seen[code]; ok {
+			t.Errorf("%s and %s both have code %q", prev, name, code)
+		}
+		seen[code] = name
+	}
+}
+
+func TestGroupByBlock(t *testing.T) {
+	t.Parallel()
+
+	f := Parse(byteLines(
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc : https://duck.com",
+		"// Submitted by Not A Duck <duck@duck.com>",
+		"pond.duck.com",
+		"bad_label.duck.com",
+		"",
+		"// Goose Gang : https://goose.com",
+		"goose.com",
+		"",
+		"// Swan Ltd : https://swan.com",
+		"// Submitted by Not A Swan <swan@swan.com>",
+		"swan.com",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+		"",
+	))
+	fileErr := errors.New("not located anywhere")
+	errs := append(slices.Clone(f.Errors), f.Warnings...)
+	errs = append([]error{fileErr}, errs...)
+
+	var got []string
+	for _, g := range GroupByBlock(f, errs) {
+		got = append(got, g.Heading())
+	}
+	want := []string{
+		`"DuckCorp Inc" at lines 3-6: 2 issues`,
+		`"Goose Gang" at lines 8-9: 1 issue`,
+		`Other: 1 issue`,
+	}
+	checkDiff(t, "group headings", got, want)
+
+	var total int
+	for _, g := range GroupByBlock(f, errs) {
+		total += len(g.Errors)
+	}
+	if total != len(errs) {
+		t.Errorf("groups have %d errors, want %d", total, len(errs))
+	}
+	if got := GroupByBlock(f, nil); got != nil {
+		t.Errorf("GroupByBlock of no errors = %v, want nil", got)
+	}
+}
+
+func TestGitHubAnnotation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		info  ErrorInfo
+		level string
+		want  string
+	}{
+		{
+			name: "single_line",
+			info: ErrorInfo{
+				Type:      "unsorted_suffixes",
+				Message:   `suffix "example.com" at line 5 is not sorted correctly`,
+				Path:      "public_suffix_list.dat",
+				StartLine: 5,
+				EndLine:   5,
+			},
+			level: "warning",
+			want:  `::warning file=public_suffix_list.dat,line=5,title=unsorted_suffixes::suffix "example.com" at line 5 is not sorted correctly`,
+		},
+		{
+			name: "multi_line",
+			info: ErrorInfo{
+				Type:      "missing_entity_email",
+				Message:   `could not find a contact email for "DuckCorp Inc" at lines 4-5`,
+				Path:      "public_suffix_list.dat",
+				StartLine: 4,
+				EndLine:   5,
+			},
+			level: "error",
+			want:  `::error file=public_suffix_list.dat,line=4,endLine=5,title=missing_entity_email::could not find a contact email for "DuckCorp Inc" at lines 4-5`,
+		},
+		{
+			name: "no_location",
+			info: ErrorInfo{
+				Type:    "utf8_bom",
+				Message: "file starts with an unnecessary UTF-8 BOM (byte order mark)",
+				Path:    "public_suffix_list.dat",
+			},
+			level: "error",
+			want:  `::error file=public_suffix_list.dat,title=utf8_bom::file starts with an unnecessary UTF-8 BOM (byte order mark)`,
+		},
+		{
+			name: "escaping",
+			info: ErrorInfo{
+				Type:      "error",
+				Message:   "100% broken\nsecond line",
+				Path:      "a,b:c.dat",
+				StartLine: 1,
+				EndLine:   1,
+			},
+			level: "error",
+			want:  `::error file=a%2Cb%3Ac.dat,line=1,title=error::100%25 broken%0Asecond line`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.info.GitHubAnnotation(test.level)
+			if got != test.want {
+				t.Errorf("GitHubAnnotation(%q) wrong:\n got: %s\nwant: %s", test.level, got, test.want)
+			}
+		})
+	}
+}
+
+func TestWithUnicodeDomains(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{
+			in:   `suffix "xn--e1afmkfd.xn--p1ai" at line 5 is not in canonical form`,
+			want: `suffix "xn--e1afmkfd.xn--p1ai" (пример.рф) at line 5 is not in canonical form`,
+		},
+		{
+			in:   `wildcard "*.xn--p1ai" and exception "!www.xn--p1ai"`,
+			want: `wildcard "*.xn--p1ai" (*.рф) and exception "!www.xn--p1ai" (!www.рф)`,
+		},
+		{
+			in:   `contact email "duck@xn--bcher-kva.example" is invalid`,
+			want: `contact email "duck@xn--bcher-kva.example" (bücher.example) is invalid`,
+		},
+		{
+			// An "xn--" in the middle of a label is not punycode.
+			in:   `suffix "fooxn--p1ai" and suffix "foo-xn--p1ai.example" at line 3`,
+			want: `suffix "fooxn--p1ai" and suffix "foo-xn--p1ai.example" at line 3`,
+		},
+		{
+			in:   `suffix "foo.xn--p1ai" at line 3, xn--p1ai`,
+			want: `suffix "foo.xn--p1ai" (foo.рф) at line 3, xn--p1ai (рф)`,
+		},
+		{
+			// Not punycode, or not valid punycode.
+			in:   `suffix "example.com" and suffix "xn--ab-cd.example" at line 7`,
+			want: `suffix "example.com" and suffix "xn--ab-cd.example" at line 7`,
+		},
+	}
+
+	for _, test := range tests {
+		if got := WithUnicodeDomains(test.in); got != test.want {
+			t.Errorf("WithUnicodeDomains(%q) = %q, want %q", test.in, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package parser
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// The subset of the SARIF 2.1.0 format that WriteSARIF produces. See
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+// for the full specification.
+type (
+	sarifLog struct {
+		Schema  string     `json:"$schema"`
+		Version string     `json:"version"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name           string      `json:"name"`
+		InformationURI string      `json:"informationUri"`
+		Rules          []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID string `json:"id"`
+	}
+	sarifResult struct {
+		RuleID    string          `json:"ruleId"`
+		Level     string          `json:"level"`
+		Message   sarifMessage    `json:"message"`
+		Locations []sarifLocation `json:"locations,omitempty"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifLocation struct {
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	}
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Region           *sarifRegion          `json:"region,omitempty"`
+	}
+	sarifArtifactLocation struct {
+		URI string `json:"uri"`
+	}
+	sarifRegion struct {
+		StartLine  int  `json:"startLine"`
+		EndLine    int  `json:"endLine"`
+		ByteOffset *int `json:"byteOffset,omitempty"`
+		ByteLength *int `json:"byteLength,omitempty"`
+	}
+)
+
+// WriteSARIF writes errs and warnings to w as a SARIF 2.1.0 log, for
+// uploading to code scanning tools such as GitHub's. Each error is a
+// result whose rule is its Type, at level "error" or "warning".
+//
+// Results are only anchored to a file location if they have a Path,
+// because SARIF locations must point at a file.
+func WriteSARIF(w io.Writer, errs, warnings []ErrorInfo) error {
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           "govalidate",
+				InformationURI: "https://github.com/publicsuffix/list",
+				Rules:          []sarifRule{},
+			},
+		},
+		Results: []sarifResult{},
+	}
+
+	seen := map[string]bool{}
+	add := func(info ErrorInfo, level string) {
+		if !seen[info.Type] {
+			seen[info.Type] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: info.Type})
+		}
+
+		result := sarifResult{
+			RuleID:  info.Type,
+			Level:   level,
+			Message: sarifMessage{Text: info.Message},
+		}
+		if info.Path != "" {
+			loc := sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: info.Path},
+			}
+			if info.StartLine > 0 {
+				loc.Region = &sarifRegion{
+					StartLine: info.StartLine,
+					EndLine:   info.EndLine,
+				}
+				if info.EndByte > info.StartByte {
+					length := info.EndByte - info.StartByte
+					loc.Region.ByteOffset = &info.StartByte
+					loc.Region.ByteLength = &length
+				}
+			}
+			result.Locations = []sarifLocation{{PhysicalLocation: loc}}
+		}
+		run.Results = append(run.Results, result)
+	}
+	for _, info := range errs {
+		add(info, "error")
+	}
+	for _, info := range warnings {
+		add(info, "warning")
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
@@ -0,0 +1,144 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestWriteSARIF(t *testing.T) {
+	t.Parallel()
+
+	errs := []ErrorInfo{
+		{
+			Type:      "missing_entity_email",
+			Message:   `could not find a contact email for "DuckCorp Inc" at psl.dat:4-5`,
+			Path:      "psl.dat",
+			StartLine: 4,
+			EndLine:   5,
+			StartByte: 0,
+			EndByte:   50,
+		},
+		{
+			Type:    "utf8_bom",
+			Message: "file starts with an unnecessary UTF-8 BOM (byte order mark)",
+		},
+	}
+	warnings := []ErrorInfo{
+		{
+			Type:      "long_line",
+			Message:   "line is too long",
+			Path:      "psl.dat",
+			StartLine: 7,
+			EndLine:   7,
+		},
+		{
+			Type:      "missing_entity_email",
+			Message:   `could not find a contact email for "Goose" at psl.dat:9-10`,
+			Path:      "psl.dat",
+			StartLine: 9,
+			EndLine:   10,
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, errs, warnings); err != nil {
+		t.Fatal(err)
+	}
+
+	// Check the output against the parts of the SARIF 2.1.0 schema
+	// that WriteSARIF uses: required properties, and the allowed
+	// values for levels and locations.
+	var log struct {
+		Schema  string `json:"$schema"`
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name           string `json:"name"`
+					InformationURI string `json:"informationUri"`
+					Rules          []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID  string `json:"ruleId"`
+				Level   string `json:"level"`
+				Message struct {
+					Text string `json:"text"`
+				} `json:"message"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region *struct {
+							StartLine  int  `json:"startLine"`
+							EndLine    int  `json:"endLine"`
+							ByteOffset *int `json:"byteOffset"`
+							ByteLength *int `json:"byteLength"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	dec := json.NewDecoder(&buf)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&log); err != nil {
+		t.Fatalf("output is not the expected JSON: %v", err)
+	}
+
+	if log.Version != "2.1.0" || log.Schema == "" {
+		t.Errorf("got version %q and schema %q, want 2.1.0 and a schema", log.Version, log.Schema)
+	}
+	if len(log.Runs) != 1 {
+		t.Fatalf("got %d runs, want 1", len(log.Runs))
+	}
+	run := log.Runs[0]
+	if run.Tool.Driver.Name == "" {
+		t.Error("tool driver has no name")
+	}
+	var rules []string
+	for _, rule := range run.Tool.Driver.Rules {
+		rules = append(rules, rule.ID)
+	}
+	checkDiff(t, "rules", rules, []string{"missing_entity_email", "utf8_bom", "long_line"})
+
+	var got []string
+	for _, result := range run.Results {
+		if result.Message.Text == "" {
+			t.Errorf("result %q has no message", result.RuleID)
+		}
+		if result.Level != "error" && result.Level != "warning" {
+			t.Errorf("result %q has invalid level %q", result.RuleID, result.Level)
+		}
+		desc := result.Level + " " + result.RuleID
+		for _, loc := range result.Locations {
+			if loc.PhysicalLocation.ArtifactLocation.URI == "" {
+				t.Errorf("result %q has a location without a URI", result.RuleID)
+			}
+			if r := loc.PhysicalLocation.Region; r != nil {
+				if r.StartLine < 1 || r.EndLine < r.StartLine {
+					t.Errorf("result %q has invalid region lines %d-%d", result.RuleID, r.StartLine, r.EndLine)
+				}
+				if (r.ByteOffset == nil) != (r.ByteLength == nil) {
+					t.Errorf("result %q has only one of byteOffset and byteLength", result.RuleID)
+				}
+				desc += " " + loc.PhysicalLocation.ArtifactLocation.URI
+				if r.ByteOffset != nil {
+					desc += " bytes"
+				}
+			}
+		}
+		got = append(got, desc)
+	}
+	want := []string{
+		"error missing_entity_email psl.dat bytes",
+		"error utf8_bom",
+		"warning long_line psl.dat",
+		"warning missing_entity_email psl.dat",
+	}
+	checkDiff(t, "results", got, want)
+}
@@ -0,0 +1,56 @@
+package parser
+
+import "strings"
+
+// FileStats are summary statistics about a parsed PSL file, suitable
+// for JSON encoding.
+type FileStats struct {
+	// Sections is the number of sections in the file.
+	Sections int `json:"sections"`
+	// ICANN and Private are statistics about the ICANN DOMAINS and
+	// PRIVATE DOMAINS sections respectively.
+	ICANN   SectionStats `json:"icann"`
+	Private SectionStats `json:"private"`
+}
+
+// SectionStats are summary statistics about one section of a PSL
+// file.
+type SectionStats struct {
+	// Entities is the number of blocks of suffixes in the section.
+	Entities int `json:"entities"`
+	// Suffixes is the total number of entries in the section,
+	// including wildcards and exceptions.
+	Suffixes int `json:"suffixes"`
+	// Wildcards is the number of wildcard entries (for example
+	// "*.example.com").
+	Wildcards int `json:"wildcards"`
+	// Exceptions is the number of exception entries (for example
+	// "!www.example.com").
+	Exceptions int `json:"exceptions"`
+}
+
+// Stats returns summary statistics about f.
+func Stats(f *File) FileStats {
+	var ret FileStats
+	for _, block := range f.Blocks {
+		if _, ok := block.(StartSection); ok {
+			ret.Sections++
+		}
+	}
+	add := func(stats *SectionStats, blocks []Suffixes) {
+		for _, block := range blocks {
+			stats.Entities++
+			for _, entry := range block.Entries {
+				stats.Suffixes++
+				if strings.HasPrefix(entry.Text(), "*.") {
+					stats.Wildcards++
+				} else if strings.HasPrefix(entry.Text(), "!") {
+					stats.Exceptions++
+				}
+			}
+		}
+	}
+	add(&ret.ICANN, f.SuffixBlocksInSection("ICANN DOMAINS"))
+	add(&ret.Private, f.SuffixBlocksInSection("PRIVATE DOMAINS"))
+	return ret
+}
@@ -0,0 +1,44 @@
+package parser
+
+import "testing"
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+
+	f := Parse(byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// example : https://example.com",
+		"example",
+		"*.example",
+		"!www.example",
+		"",
+		"com",
+		"",
+		"// ===END ICANN DOMAINS===",
+		"",
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc : https://example.com",
+		"// Submitted by Not A Duck <duck@example.com>",
+		"example.com",
+		"*.example.com",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+	))
+	want := FileStats{
+		Sections: 2,
+		ICANN: SectionStats{
+			Entities:   2,
+			Suffixes:   4,
+			Wildcards:  1,
+			Exceptions: 1,
+		},
+		Private: SectionStats{
+			Entities:  1,
+			Suffixes:  2,
+			Wildcards: 1,
+		},
+	}
+	checkDiff(t, "Stats", Stats(f), want)
+}
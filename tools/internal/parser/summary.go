@@ -0,0 +1,80 @@
+package parser
+
+import "encoding/json"
+
+// ValidationSummary records which validations ran on a PSL file, and
+// what each of them found. Its JSON encoding is a rollup by category,
+// see CategorySummary.
+//
+// Validations don't run at all on files with parse errors, in which
+// case the summary is empty.
+type ValidationSummary struct {
+	// Checks are the validations that ran, in the order they ran.
+	Checks []CheckResult
+}
+
+// CheckResult is the outcome of running one validation.
+type CheckResult struct {
+	// Name identifies the validation, for example "sorted_suffixes".
+	Name string
+	// Category is the group of related validations that this one
+	// belongs to, for example "sorting".
+	Category string
+	// Errors and Warnings are the findings of the validation, split
+	// like File.Errors and File.Warnings.
+	Errors   []error
+	Warnings []error
+}
+
+// Passed reports whether the validation found no errors. Warnings
+// don't cause a validation to fail.
+func (r CheckResult) Passed() bool {
+	return len(r.Errors) == 0
+}
+
+// CategorySummary is the rollup of the validations in one category,
+// suitable for JSON encoding.
+type CategorySummary struct {
+	// Category is the name of the category, for example "sorting".
+	Category string `json:"category"`
+	// Checks is the number of validations in the category that ran,
+	// and Passed is how many of them found no errors.
+	Checks int `json:"checks"`
+	Passed int `json:"passed"`
+	// Errors and Warnings are the findings of the category's
+	// validations.
+	Errors   []ErrorInfo `json:"errors,omitempty"`
+	Warnings []ErrorInfo `json:"warnings,omitempty"`
+}
+
+// Categories returns the rollup of s by category, in the order each
+// category first ran.
+func (s ValidationSummary) Categories() []CategorySummary {
+	var ret []CategorySummary
+	index := map[string]int{}
+	for _, check := range s.Checks {
+		i, ok := index[check.Category]
+		if !ok {
+			i = len(ret)
+			index[check.Category] = i
+			ret = append(ret, CategorySummary{Category: check.Category})
+		}
+		cat := &ret[i]
+		cat.Checks++
+		if check.Passed() {
+			cat.Passed++
+		}
+		cat.Errors = append(cat.Errors, Describe("", check.Errors)...)
+		cat.Warnings = append(cat.Warnings, Describe("", check.Warnings)...)
+	}
+	return ret
+}
+
+// MarshalJSON encodes s as the list of its Categories.
+func (s ValidationSummary) MarshalJSON() ([]byte, error) {
+	cats := s.Categories()
+	if cats == nil {
+		cats = []CategorySummary{}
+	}
+	return json.Marshal(cats)
+}
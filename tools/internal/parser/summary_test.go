@@ -0,0 +1,96 @@
+package parser
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestValidationSummary(t *testing.T) {
+	t.Parallel()
+
+	f, summary := ParseWithSummary(byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"// com : https://en.wikipedia.org/wiki/.com",
+		"com",
+		"",
+		"// ===END ICANN DOMAINS===",
+		"",
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// DuckCorp Inc : https://duck.com",
+		"// Submitted by Not A Duck <duck@duck.com>",
+		"pond.duck.com",
+		"b.duck.com",
+		"",
+		"// Bad Label Inc : https://bad.com",
+		"// Submitted by Not A Duck <duck@bad.com>",
+		"bad_label.bad.com",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+		"",
+	), "", Options{})
+
+	if got, want := len(summary.Checks), len(validations); got != want {
+		t.Errorf("summary has %d checks, want %d", got, want)
+	}
+	var errs, warnings int
+	for _, check := range summary.Checks {
+		errs += len(check.Errors)
+		warnings += len(check.Warnings)
+	}
+	if errs != len(f.Errors) || warnings != len(f.Warnings) {
+		t.Errorf("summary has %d errors and %d warnings, file has %d and %d", errs, warnings, len(f.Errors), len(f.Warnings))
+	}
+
+	type rollup struct {
+		Category                         string
+		Checks, Passed, Errors, Warnings int
+	}
+	var got []rollup
+	for _, cat := range summary.Categories() {
+		got = append(got, rollup{cat.Category, cat.Checks, cat.Passed, len(cat.Errors), len(cat.Warnings)})
+	}
+	want := []rollup{
+		{"metadata", 13, 13, 0, 0},
+		{"suffixes", 4, 4, 0, 0},
+		{"sorting", 2, 2, 0, 1},
+		{"sections", 4, 4, 0, 0},
+		{"maintainers", 5, 5, 0, 0},
+		{"wildcards", 3, 3, 0, 0},
+		{"labels", 5, 4, 1, 0},
+	}
+	checkDiff(t, "summary categories", got, want)
+
+	bs, err := json.Marshal(summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded []CategorySummary
+	if err := json.Unmarshal(bs, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	checkDiff(t, "decoded summary", decoded, summary.Categories())
+}
+
+func TestValidationSummaryParseError(t *testing.T) {
+	t.Parallel()
+
+	// Validations don't run on files with parse errors.
+	_, summary := ParseWithSummary(byteLines(
+		"// ===BEGIN ICANN DOMAINS===",
+		"",
+		"com",
+		"",
+	), "", Options{})
+	if len(summary.Checks) != 0 {
+		t.Errorf("summary has %d checks, want none", len(summary.Checks))
+	}
+	bs, err := json.Marshal(summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(bs), "[]"; got != want {
+		t.Errorf("JSON summary is %s, want %s", got, want)
+	}
+}
@@ -24,24 +24,56 @@ type Source struct {
 	// lineOffset is how many lines are before the beginning of lines,
 	// for sources that represent a subset of the input.
 	lineOffset int
+	// path is the path of the file the source text came from, if
+	// known.
+	path string
+	// offsets are the byte offsets in the input file of the start
+	// and end of each line of lines, in the half-open form
+	// [start:end). They are nil if unknown, for example because the
+	// input was not UTF-8.
+	offsets [][2]int
 }
 
-// newSource returns a source for bs, along with a preliminary set of
-// input validation errors.
+// newSource returns a source for bs, which was read from the file at
+// path, along with a preliminary set of input validation errors. path
+// may be empty if the origin of bs is unknown. Lines longer than
+// maxLineLength characters are reported, unless maxLineLength is zero.
 //
 // source always returns a usable, non-nil result, even when it
 // returns errors.
-func newSource(bs []byte) (Source, []error) {
-	lines, errs := normalizeToUTF8Lines(bs)
+func newSource(bs []byte, path string, maxLineLength int) (Source, []error) {
+	lines, offsets, errs := normalizeToUTF8Lines(bs, path, maxLineLength)
 
 	ret := Source{
 		lines:      lines,
 		lineOffset: 0,
+		path:       path,
+		offsets:    offsets,
 	}
 
 	return ret, errs
 }
 
+// trailingNewlineError returns a TrailingNewlineError if src, the
+// source of an entire file, does not end with exactly one newline, or
+// nil if it does. Files with no content at all are not reported.
+func trailingNewlineError(src Source) error {
+	// A file that ends with exactly one newline splits into lines
+	// that end with a single empty string.
+	last := len(src.lines) - 1
+	for last >= 0 && src.lines[last] == "" {
+		last--
+	}
+	newlines := len(src.lines) - 1 - last
+	if last < 0 || newlines == 1 {
+		return nil
+	}
+	return TrailingNewlineError{
+		Line:     src.line(last),
+		Newlines: newlines,
+	}
+}
+
 // Text returns the source text of s as a string.
 func (s Source) Text() string {
 	if len(s.lines) == 1 {
@@ -51,13 +83,10 @@ func (s Source) Text() string {
 }
 
 // LocationString returns a short string describing the source
-// location.
+// location. Sources with a known path are described as "path:line" or
+// "path:start-end", otherwise as "line N" or "lines start-end".
 func (s Source) LocationString() string {
-	// For printing diagnostics, 0-indexed [start:end) is confusing
-	// and not how editors present text to people. Adjust the offsets
-	// to be 1-indexed [start:end] instead.
-	start := s.lineOffset + 1
-	end := s.lineOffset + len(s.lines)
+	start, end := s.lineRange()
 
 	if end < start {
 		// Zero line Source. We can sometimes produce these internally
@@ -67,12 +96,43 @@ func (s Source) LocationString() string {
 		return fmt.Sprintf("<invalid Source, 0-line range before line %d>", start)
 	}
 
+	if s.path != "" {
+		if start == end {
+			return fmt.Sprintf("%s:%d", s.path, start)
+		}
+		return fmt.Sprintf("%s:%d-%d", s.path, start, end)
+	}
 	if start == end {
 		return fmt.Sprintf("line %d", start)
 	}
 	return fmt.Sprintf("lines %d-%d", start, end)
 }
 
+// Path returns the path of the file that s came from, or the empty
+// string if unknown.
+func (s Source) Path() string {
+	return s.path
+}
+
+// lineRange returns the first and last line numbers of s.
+//
+// For printing diagnostics, 0-indexed [start:end) is confusing and
+// not how editors present text to people. lineRange returns 1-indexed
+// [start:end] line numbers instead.
+func (s Source) lineRange() (start, end int) {
+	return s.lineOffset + 1, s.lineOffset + len(s.lines)
+}
+
+// byteRange returns the byte offsets in the input file of the start
+// and end of s, in the half-open form [start:end). ok is false if the
+// offsets are unknown.
+func (s Source) byteRange() (start, end int, ok bool) {
+	if len(s.offsets) == 0 {
+		return 0, 0, false
+	}
+	return s.offsets[0][0], s.offsets[len(s.offsets)-1][1], true
+}
+
 // slice returns the slice of s between startLine and endLine.
 //
 // startLine and endLine behave like normal slice offsets, i.e. they
@@ -81,10 +141,15 @@ func (s Source) slice(startLine, endLine int) Source {
 	if startLine < 0 || startLine > len(s.lines) || endLine < startLine || endLine > len(s.lines) {
 		panic("invalid input to slice")
 	}
-	return Source{
+	ret := Source{
 		lines:      s.lines[startLine:endLine],
 		lineOffset: s.lineOffset + startLine,
+		path:       s.path,
+	}
+	if s.offsets != nil {
+		ret.offsets = s.offsets[startLine:endLine]
 	}
+	return ret
 }
 
 // line returns the nth line of s.
@@ -190,9 +255,12 @@ var (
 // Windows software, normalizeToUTF8Lines accepts input encoded as
 // UTF-8, UTF-16LE or UTF-16BE, with or without a leading BOM.
 //
-// normalizeToUTF8Lines returns the normalized lines of bs, as well as
-// errors that report deviations from the canonical encoding, if any.
-func normalizeToUTF8Lines(bs []byte) ([]string, []error) {
+// normalizeToUTF8Lines returns the normalized lines of bs, the byte
+// offsets of each normalized line in bs (see Source.offsets), as well
+// as errors that report deviations from the canonical encoding and
+// lines longer than maxLineLength, if any. Offsets are only returned
+// for UTF-8 input.
+func normalizeToUTF8Lines(bs []byte, path string, maxLineLength int) ([]string, [][2]int, []error) {
 	var errs []error
 
 	enc := utf8Transform
@@ -215,19 +283,29 @@ func normalizeToUTF8Lines(bs []byte) ([]string, []error) {
 		}
 	}
 
+	raw := bs
 	bs, err := enc.NewDecoder().Bytes(bs)
 	if err != nil {
 		// The decoder shouldn't error out, if it does we can't really
 		// proceed, just return the errors we've found so far.
 		errs = append(errs, err)
-		return []string{}, errs
+		return []string{}, nil, errs
 	}
 
 	if len(bs) == 0 {
-		return []string{}, errs
+		return []string{}, nil, errs
 	}
 
+	// Decoding UTF-16 changes the length of everything, so byte
+	// offsets are only meaningful for UTF-8 input. Those are computed
+	// from the raw input, because the decoder changes the length of
+	// invalid UTF-8 sequences.
 	ret := strings.Split(string(bs), "\n")
+	var rawLines, offsets [][2]int
+	if enc == utf8Transform {
+		rawLines = lineSpans(raw)
+		offsets = make([][2]int, len(rawLines))
+	}
 	for i, line := range ret {
 		// capture source info before we tidy up the line starts/ends,
 		// so that input normalization errors show the problem being
@@ -242,6 +320,15 @@ func normalizeToUTF8Lines(bs []byte) ([]string, []error) {
 		src := Source{
 			lineOffset: i,
 			lines:      []string{line},
+			path:       path,
+		}
+		if rawLines != nil {
+			span := rawLines[i]
+			src.offsets = [][2]int{span}
+
+			text := raw[span[0]:span[1]]
+			start := span[0] + len(text) - len(bytes.TrimLeftFunc(text, unicode.IsSpace))
+			offsets[i] = [2]int{start, start + len(bytes.TrimFunc(text, unicode.IsSpace))}
 		}
 		if strings.ContainsRune(line, utf8.RuneError) {
 			errs = append(errs, InvalidUTF8Error{src})
@@ -261,9 +348,34 @@ func normalizeToUTF8Lines(bs []byte) ([]string, []error) {
 			ret[i] = line
 			errs = append(errs, LeadingWhitespaceError{src})
 		}
+		if strings.ContainsRune(line, '\t') {
+			errs = append(errs, TabCharacterError{src})
+		}
+		if maxLineLength > 0 && utf8.RuneCountInString(line) > maxLineLength {
+			errs = append(errs, LongLineError{src, maxLineLength})
+		}
 	}
 
-	return ret, errs
+	return ret, offsets, errs
+}
+
+// lineSpans returns the byte offsets of the start and end of each
+// line of bs, not including the newline, in the half-open form
+// [start:end). A leading UTF-8 BOM is not part of the first line.
+func lineSpans(bs []byte) [][2]int {
+	start := 0
+	if bytes.HasPrefix(bs, []byte(bomUTF8)) {
+		start = len(bomUTF8)
+	}
+	var ret [][2]int
+	for {
+		end := bytes.IndexByte(bs[start:], '\n')
+		if end == -1 {
+			return append(ret, [2]int{start, len(bs)})
+		}
+		ret = append(ret, [2]int{start, start + end})
+		start += end + 1
+	}
 }
 
 // guessUTFVariant guesses the encoding of bs.
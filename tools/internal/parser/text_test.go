@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"golang.org/x/text/encoding"
 	"golang.org/x/text/encoding/unicode"
 )
@@ -199,6 +200,46 @@ func TestNormalize(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "inner_tabs",
+			in: byteLines(
+				"a file\twith",
+				"some\t\ttabs",
+				"and one good line",
+			),
+			want: []string{
+				"a file\twith",
+				"some\t\ttabs",
+				"and one good line",
+			},
+			wantErrs: []error{
+				TabCharacterError{
+					Line: mkSrc(0, "a file\twith"),
+				},
+				TabCharacterError{
+					Line: mkSrc(1, "some\t\ttabs"),
+				},
+			},
+		},
+		{
+			name: "long_lines",
+			in: byteLines(
+				strings.Repeat("a", 80),
+				strings.Repeat("b", 81),
+				strings.Repeat("ü", 80),
+			),
+			want: []string{
+				strings.Repeat("a", 80),
+				strings.Repeat("b", 81),
+				strings.Repeat("ü", 80),
+			},
+			wantErrs: []error{
+				LongLineError{
+					Line: mkSrc(1, strings.Repeat("b", 81)),
+					Max:  80,
+				},
+			},
+		},
 		{
 			name: "the_most_wrong_line",
 			in:   byteLines("\xef\xbb\xbf  \t  // Hello\xc3\x28 very broken line\t  \r"),
@@ -223,7 +264,7 @@ func TestNormalize(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			src, errs := newSource(tc.in)
+			src, errs := newSource(tc.in, "", DefaultMaxLineLength)
 			checkDiff(t, "newSource error set", errs, tc.wantErrs)
 			checkDiff(t, "newSource result", src.lines, tc.want)
 		})
@@ -633,9 +674,159 @@ func utf8WithBOM(s string) []byte {
 	return encodeFromUTF8(s, unicode.UTF8BOM)
 }
 
+// checkDiff reports a test error if got and want differ. Byte offsets
+// of Sources are ignored, they are tested separately in
+// TestByteOffsets.
 func checkDiff(t *testing.T, whatIsBeingDiffed string, got, want any) {
 	t.Helper()
-	if diff := cmp.Diff(got, want, cmp.AllowUnexported(Source{})); diff != "" {
+	if diff := cmp.Diff(got, want, cmp.AllowUnexported(Source{}), cmpopts.IgnoreFields(Source{}, "offsets"), cmpopts.IgnoreFields(File{}, "blankLines")); diff != "" {
 		t.Errorf("%s is wrong (-got+want):\n%s", whatIsBeingDiffed, diff)
 	}
 }
+
+func TestByteOffsets(t *testing.T) {
+	t.Parallel()
+
+	in := []byte(bomUTF8 + "// Bücher: https://example.com\n" +
+		"  bücher.example \r\n" +
+		"\n" +
+		"пример.рф\n" +
+		"bad\xffbyte.example")
+
+	src, _ := newSource(in, "", DefaultMaxLineLength)
+	want := [][2]int{
+		{3, 34},  // "// Bücher: ..." after the BOM, ü is 2 bytes
+		{37, 52}, // without the leading spaces and trailing " \r"
+		{55, 55}, // empty line
+		{56, 73}, // 2 bytes per Cyrillic letter
+		{74, 90}, // the invalid byte counts as 1 byte, not as U+FFFD
+	}
+	checkDiff(t, "offsets", src.offsets, want)
+
+	// The offsets select the normalized text in the raw input, as
+	// long as it's valid UTF-8.
+	for i, line := range src.lines[:4] {
+		if got := string(in[want[i][0]:want[i][1]]); got != line {
+			t.Errorf("input at offsets of line %d is %q, want %q", i, got, line)
+		}
+	}
+
+	if start, end, ok := src.slice(1, 4).byteRange(); !ok || start != 37 || end != 73 {
+		t.Errorf("byteRange of lines 1-3 = %d, %d, %v, want 37, 73, true", start, end, ok)
+	}
+
+	// Errors about the raw line cover all of it.
+	_, errs := newSource([]byte("example.com\n  example.org"), "", DefaultMaxLineLength)
+	for _, err := range errs {
+		if e, ok := err.(LeadingWhitespaceError); ok {
+			if start, end, _ := e.Line.byteRange(); start != 12 || end != 25 {
+				t.Errorf("byteRange of leading whitespace error = %d, %d, want 12, 25", start, end)
+			}
+		}
+	}
+
+	// UTF-16 input has no meaningful byte offsets.
+	utf16, err := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder().Bytes([]byte("example.com\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, _ = newSource(utf16, "", DefaultMaxLineLength)
+	if _, _, ok := src.byteRange(); ok {
+		t.Error("UTF-16 input has byte offsets, want none")
+	}
+}
+
+func TestTrailingNewline(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   string
+		want error
+	}{
+		{"one newline", "example.com\n", nil},
+		{"empty file", "", nil},
+		{"only newlines", "\n\n", nil},
+		{
+			"no newline",
+			"example.com\nexample.org",
+			TrailingNewlineError{
+				Line:     mkSrc(1, "example.org"),
+				Newlines: 0,
+			},
+		},
+		{
+			"two newlines",
+			"example.com\nexample.org\n\n",
+			TrailingNewlineError{
+				Line:     mkSrc(1, "example.org"),
+				Newlines: 2,
+			},
+		},
+		{
+			"blank line with spaces",
+			"example.com\n \n",
+			TrailingNewlineError{
+				Line:     mkSrc(0, "example.com"),
+				Newlines: 2,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			src, _ := newSource([]byte(tc.in), "", DefaultMaxLineLength)
+			checkDiff(t, "trailing newline error", trailingNewlineError(src), tc.want)
+		})
+	}
+}
+
+func TestMaxLineLengthOption(t *testing.T) {
+	t.Parallel()
+
+	in := byteLines(
+		"// "+strings.Repeat("a", 20),
+		"// "+strings.Repeat("b", 80),
+		"",
+	)
+	longLines := func(opts Options) []string {
+		var ret []string
+		for _, err := range ParseWithOptions(in, "", opts).Warnings {
+			if v, ok := err.(LongLineError); ok {
+				ret = append(ret, fmt.Sprintf("%d>%d", v.Line.lineOffset, v.Max))
+			}
+		}
+		return ret
+	}
+
+	checkDiff(t, "default max line length", longLines(Options{}), []string{"1>80"})
+	checkDiff(t, "custom max line length", longLines(Options{MaxLineLength: 10}), []string{"0>10", "1>10"})
+	checkDiff(t, "disabled max line length", longLines(Options{MaxLineLength: -1}), []string(nil))
+}
+
+func TestTabCharacterIsWarning(t *testing.T) {
+	t.Parallel()
+
+	// A tab is cosmetic, and must not stop validation of the rest of
+	// the file.
+	f := Parse(byteLines(
+		"// ===BEGIN PRIVATE DOMAINS===",
+		"",
+		"// A\tcomment",
+		"",
+		"// DuckCorp Inc : https://duck.com",
+		"duck.com",
+		"",
+		"// ===END PRIVATE DOMAINS===",
+		"",
+	))
+	var errs, warnings []string
+	for _, err := range f.Errors {
+		errs = append(errs, errorType(err))
+	}
+	for _, err := range f.Warnings {
+		warnings = append(warnings, errorType(err))
+	}
+	checkDiff(t, "errors", errs, []string{"missing_entity_email"})
+	checkDiff(t, "warnings", warnings, []string{"tab_character"})
+}
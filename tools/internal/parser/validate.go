@@ -1,5 +1,16 @@
 package parser
 
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/idna"
+)
+
 // Validate runs validations on a parsed File.
 //
 // Validation only runs on a file that does not yet have any
@@ -10,8 +21,118 @@ func (p *parser) Validate() {
 		return
 	}
 
-	p.requireEntityNames()
-	p.requirePrivateDomainEmailContact()
+	for _, v := range validations {
+		if p.ctx != nil && p.ctx.Err() != nil {
+			return
+		}
+		p.check(v)
+	}
+}
+
+// ValidateSuffixes runs the validations that only need a single block
+// of suffixes on block, as if it were in the named file section
+// ("ICANN DOMAINS" or "PRIVATE DOMAINS", or the empty string for no
+// section). It returns the errors and warnings found, split like
+// File.Errors and File.Warnings.
+//
+// Validations that compare a block to the rest of the file, such as
+// checking for duplicate entity names or that exceptions match a
+// wildcard, are not run. opts configures the validations that are run,
+// like for ParseWithOptions.
+func ValidateSuffixes(block Suffixes, section string, opts Options) (errs, warnings []error) {
+	p := parser{
+		downgradeToWarning: downgradeToWarning,
+		opts:               opts,
+	}
+	if section != "" {
+		p.addBlock(StartSection{Name: section})
+	}
+	p.addBlock(block)
+	if section != "" {
+		p.addBlock(EndSection{Name: section})
+	}
+
+	for _, v := range validations {
+		if v.singleBlock {
+			p.check(v)
+		}
+	}
+
+	return p.Errors, p.Warnings
+}
+
+// validation is one of the validations run by Validate.
+type validation struct {
+	// name identifies the validation in validation summaries.
+	name string
+	// category groups related validations in validation summaries.
+	category string
+	// singleBlock is whether the validation only looks at one block
+	// at a time, and so is also run by ValidateSuffixes.
+	singleBlock bool
+	run         func(*parser)
+}
+
+// validations are the validations run by Validate, in order.
+var validations = []validation{
+	{"entity_names", "metadata", true, (*parser).requireEntityNames},
+	{"valid_entity_names", "metadata", true, (*parser).validateEntityNames},
+	{"balanced_headers", "metadata", true, (*parser).requireBalancedHeaders},
+	{"private_email_contact", "metadata", true, (*parser).requirePrivateDomainEmailContact},
+	{"valid_entity_emails", "metadata", true, (*parser).validateEntityEmails},
+	{"valid_entity_urls", "metadata", true, (*parser).validateEntityURLs},
+	{"placeholder_metadata", "metadata", true, (*parser).rejectPlaceholderMetadata},
+	{"generic_entity_names", "metadata", true, (*parser).warnGenericEntityNames},
+	{"redundant_suffixes", "suffixes", true, (*parser).warnRedundantSuffixes},
+	{"canonical_suffixes", "suffixes", true, (*parser).requireCanonicalSuffixes},
+	{"sorted_suffixes", "sorting", true, (*parser).warnUnsortedSuffixes},
+	{"known_tlds", "sections", false, (*parser).requireKnownTLDs},
+	{"disjoint_sections", "sections", false, (*parser).requireDisjointSections},
+	{"unique_entity_names", "metadata", false, (*parser).requireUniqueEntityNames},
+	{"related_maintainer_emails", "maintainers", false, (*parser).warnUnrelatedMaintainerEmails},
+	{"free_email_maintainers", "maintainers", true, (*parser).warnFreeEmailMaintainers},
+	{"wildcard_exceptions", "wildcards", false, (*parser).validateWildcardExceptions},
+	{"sorted_exceptions", "sorting", true, (*parser).warnUnsortedExceptions},
+	{"valid_labels", "labels", true, (*parser).requireValidLabels},
+	{"non_empty_labels", "labels", true, (*parser).requireNonEmptyLabels},
+	{"wildcard_overlap", "wildcards", true, (*parser).requireNoWildcardOverlap},
+	{"wildcard_covered_suffixes", "wildcards", true, (*parser).requireNoWildcardCoveredSuffixes},
+	{"unique_maintainers", "maintainers", true, (*parser).requireUniqueMaintainers},
+	{"dns_length_limits", "labels", true, (*parser).requireDNSLengthLimits},
+	{"valid_punycode", "labels", true, (*parser).requireValidPunycode},
+	{"valid_hyphenation", "labels", true, (*parser).requireValidHyphenation},
+	{"no_private_tlds", "sections", true, (*parser).requireNoPrivateTLDs},
+	{"misfiled_icann_suffixes", "sections", false, (*parser).warnMisfiledICANNSuffixes},
+	{"known_email_tlds", "maintainers", false, (*parser).warnUnknownEmailTLDs},
+	{"shared_maintainers", "maintainers", false, (*parser).warnSharedMaintainers},
+	{"comment_domains", "metadata", false, (*parser).warnCommentDomainMismatches},
+	{"submission_references", "metadata", false, (*parser).requireSubmissionReferences},
+	{"merged_entities", "metadata", false, (*parser).warnMergedEntities},
+	{"entity_suffixes", "metadata", false, (*parser).requireEntitySuffixes},
+	{"unique_suffixes", "suffixes", true, (*parser).requireUniqueSuffixes},
+	{"reserved_suffixes", "suffixes", true, (*parser).rejectReservedSuffixes},
+}
+
+// check runs v, and records its findings in p.summary.
+func (p *parser) check(v validation) {
+	errs, warnings := len(p.Errors), len(p.Warnings)
+	v.run(p)
+	p.summary.Checks = append(p.summary.Checks, CheckResult{
+		Name:     v.name,
+		Category: v.category,
+		Errors:   slices.Clip(p.Errors[errs:]),
+		Warnings: slices.Clip(p.Warnings[warnings:]),
+	})
+}
+
+// icannBlocks returns the suffix blocks that validations should use
+// to look up ICANN suffixes: the ICANN section of p.opts.Reference if there
+// is one, otherwise that of the file being validated.
+func (p *parser) icannBlocks() []Suffixes {
+	if p.opts.Reference != nil {
+		return p.opts.Reference.SuffixBlocksInSection("ICANN DOMAINS")
+	}
+	return p.File.SuffixBlocksInSection("ICANN DOMAINS")
 }
 
 // requireEntityNames verifies that all Suffix blocks have some kind
@@ -26,6 +147,73 @@ func (p *parser) requireEntityNames() {
 	}
 }
 
+// validateEntityNames verifies that entity names contain no control,
+// formatting or other invisible characters, which usually sneak in
+// by copy-pasting and break searching for the name.
+func (p *parser) validateEntityNames() {
+	for _, block := range p.AllSuffixBlocks() {
+		for i, r := range block.Entity {
+			if r == ' ' || unicode.IsPrint(r) && !unicode.Is(unicode.Cf, r) {
+				continue
+			}
+			p.addError(InvalidEntityName{
+				Suffixes: block,
+				Char:     r,
+				Offset:   i,
+			})
+			break
+		}
+	}
+}
+
+// requireBalancedHeaders verifies that the brackets in the header
+// lines of private Suffix blocks are balanced and correctly nested.
+// Metadata is often written as "Name (https://example.com)" or "Name
+// <email>", and an unclosed bracket makes the metadata parse wrong
+// or not at all, usually without any other error.
+//
+// ICANN blocks are skipped, because their headers are free-form
+// notes maintained by the PSL maintainers.
+func (p *parser) requireBalancedHeaders() {
+	for _, block := range p.File.SuffixBlocksInSection("PRIVATE DOMAINS") {
+		for _, line := range block.Header {
+			if r, ok := unbalancedBracket(line.Text()); ok {
+				p.addError(MalformedEntityHeader{
+					Suffixes: block,
+					Line:     line,
+					Bracket:  r,
+				})
+				break
+			}
+		}
+	}
+}
+
+// unbalancedBracket returns the first closing bracket of s that
+// doesn't match the last open bracket, or if there is none, the last
+// bracket that is never closed. ok is false if all brackets of s are
+// balanced. Parentheses, angle brackets and square brackets are
+// checked.
+func unbalancedBracket(s string) (r rune, ok bool) {
+	open := map[rune]rune{')': '(', '>': '<', ']': '['}
+	var stack []rune
+	for _, r := range s {
+		switch r {
+		case '(', '<', '[':
+			stack = append(stack, r)
+		case ')', '>', ']':
+			if len(stack) == 0 || stack[len(stack)-1] != open[r] {
+				return r, true
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if len(stack) > 0 {
+		return stack[len(stack)-1], true
+	}
+	return 0, false
+}
+
 // requirePrivateDomainEmailContact verifies that all Suffix blocks in
 // the private section have email contact information.
 func (p *parser) requirePrivateDomainEmailContact() {
@@ -37,3 +225,1303 @@ func (p *parser) requirePrivateDomainEmailContact() {
 		}
 	}
 }
+
+// requireSubmissionReferences verifies that all Suffix blocks in the
+// private section record how they were submitted, with a "Submitted
+// by" line or a link to the pull request that added them.
+//
+// Blocks with no contact information at all are skipped, because
+// requirePrivateDomainEmailContact already reports them.
+func (p *parser) requireSubmissionReferences() {
+	for _, block := range p.File.SuffixBlocksInSection("PRIVATE DOMAINS") {
+		if block.Submitter == nil {
+			continue
+		}
+		if _, ok := block.SubmissionReference(); !ok {
+			p.addError(MissingSubmissionReference{
+				Suffixes: block,
+			})
+		}
+	}
+}
+
+// validateEntityEmails verifies that the contact email addresses of
+// all Suffix blocks have a plausible domain name. net/mail accepts
+// many technically valid addresses that cannot receive mail from the
+// internet, such as "user@localhost".
+func (p *parser) validateEntityEmails() {
+	for _, block := range p.AllSuffixBlocks() {
+		if block.Submitter == nil {
+			continue
+		}
+		addr := block.Submitter.Address
+		host := addr[strings.LastIndexByte(addr, '@')+1:]
+		if !isHostname(host) || !strings.Contains(host, ".") {
+			p.addError(InvalidEntityEmail{
+				Suffixes: block,
+			})
+		}
+	}
+}
+
+// validateEntityURLs verifies that all URLs in Suffix block headers
+// are well formed http or https URLs, and warns about URLs that use
+// plain http.
+func (p *parser) validateEntityURLs() {
+	for _, block := range p.AllSuffixBlocks() {
+		for _, line := range block.Header {
+			for _, word := range strings.Fields(line.Text()) {
+				idx := strings.Index(word, "://")
+				if idx == -1 {
+					continue
+				}
+				// URLs in free text are often wrapped in parens,
+				// preceded by a colon with no space, or followed by
+				// punctuation. Extract just the URL-shaped part.
+				start := idx
+				for start > 0 && (word[start-1] >= 'a' && word[start-1] <= 'z' || word[start-1] >= 'A' && word[start-1] <= 'Z') {
+					start--
+				}
+				word = strings.TrimRight(word[start:], ")>,.;")
+				if u := getURL(word); u == nil || !isHostname(u.Hostname()) {
+					p.addError(InvalidEntityURL{
+						Suffixes: block,
+						URL:      word,
+					})
+				}
+			}
+		}
+
+		if block.URL != nil && block.URL.Scheme == "http" {
+			p.addError(InsecureEntityURL{
+				Suffixes: block,
+			})
+		}
+	}
+}
+
+// placeholderMetadata are placeholder texts from the pull request
+// template and common examples, which submitters sometimes forget to
+// replace with their own information. They are matched
+// case-insensitively anywhere in an entity name or contact.
+var placeholderMetadata = []string{
+	"organization name",
+	"organisation name",
+	"company name",
+	"your name",
+	"your-email@example.com",
+	"your.email@example.com",
+	"you@example.com",
+	"name@example.com",
+}
+
+// rejectPlaceholderMetadata verifies that the entity name and contact
+// of Suffix blocks do not contain placeholder text. See
+// placeholderMetadata.
+func (p *parser) rejectPlaceholderMetadata() {
+	for _, block := range p.AllSuffixBlocks() {
+		fields := [][2]string{{"entity name", block.Entity}}
+		if block.Submitter != nil {
+			fields = append(fields,
+				[2]string{"contact name", block.Submitter.Name},
+				[2]string{"contact email", block.Submitter.Address})
+		}
+	checkBlock:
+		for _, field := range fields {
+			value := strings.ToLower(field[1])
+			for _, placeholder := range placeholderMetadata {
+				if strings.Contains(value, placeholder) {
+					p.addError(PlaceholderMetadata{
+						Suffixes:    block,
+						Field:       field[0],
+						Placeholder: placeholder,
+					})
+					break checkBlock
+				}
+			}
+		}
+	}
+}
+
+// genericEntityNames are the default patterns for entity names that
+// are too vague to identify a maintainer, which often mark test or
+// spam submissions. See Options.GenericEntityNames.
+var genericEntityNames = []string{
+	`test(ing)?( (company|corp|domains?|inc|ltd|org))?`,
+	`my (company|domains?|org|organi[sz]ation|projects?|sites?|website)`,
+	`personal( (domains?|projects?|sites?|website))?`,
+	`(private|home|demo|example|company|individual)`,
+	`(none|n/a|unknown|null|todo|tbd)`,
+}
+
+// defaultGenericEntityNames are genericEntityNames, compiled.
+var defaultGenericEntityNames = mustCompileGenericEntityNames(genericEntityNames)
+
+// CompileGenericEntityNames compiles patterns for use as
+// Options.GenericEntityNames. Each pattern is a regular expression
+// that is matched case-insensitively against the whole entity name.
+// It returns an error if any pattern is invalid.
+func CompileGenericEntityNames(patterns []string) ([]*regexp.Regexp, error) {
+	ret := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pat := range patterns {
+		re, err := regexp.Compile(`(?i)^(?:` + pat + `)$`)
+		if err != nil {
+			return nil, fmt.Errorf("invalid generic entity name pattern %q: %w", pat, err)
+		}
+		ret = append(ret, re)
+	}
+	return ret, nil
+}
+
+func mustCompileGenericEntityNames(patterns []string) []*regexp.Regexp {
+	ret, err := CompileGenericEntityNames(patterns)
+	if err != nil {
+		panic(err)
+	}
+	return ret
+}
+
+// warnGenericEntityNames warns about blocks in the private section
+// whose entity name matches one of the generic entity name patterns
+// in p.opts.
+func (p *parser) warnGenericEntityNames() {
+	patterns := p.opts.genericEntityNames()
+	for _, block := range p.File.SuffixBlocksInSection("PRIVATE DOMAINS") {
+		name := strings.TrimSpace(block.Entity)
+		for _, re := range patterns {
+			if re.MatchString(name) {
+				p.addError(GenericEntityName{
+					Suffixes: block,
+					Pattern:  re.String(),
+				})
+				break
+			}
+		}
+	}
+}
+
+// warnRedundantSuffixes warns about suffixes in the private section
+// that are subdomains of another suffix in the same block.
+//
+// ICANN blocks are skipped, because listing second-level suffixes
+// alongside their TLD is the norm there.
+func (p *parser) warnRedundantSuffixes() {
+	for _, block := range p.File.SuffixBlocksInSection("PRIVATE DOMAINS") {
+		suffixes := map[string]Source{}
+		for _, entry := range block.Entries {
+			if isPlainSuffix(entry.Text()) {
+				suffixes[entry.Text()] = entry
+			}
+		}
+
+		for _, entry := range block.Entries {
+			if !isPlainSuffix(entry.Text()) {
+				continue
+			}
+			for parent := parentDomain(entry.Text()); parent != ""; parent = parentDomain(parent) {
+				if parentEntry, ok := suffixes[parent]; ok {
+					p.addError(RedundantSuffix{
+						Suffixes: block,
+						Suffix:   entry,
+						Parent:   parentEntry,
+					})
+					break
+				}
+			}
+		}
+	}
+}
+
+// requireCanonicalSuffixes verifies that all suffixes are written in
+// canonical form: lowercase, and with internationalized labels in
+// their Unicode form.
+//
+// Unicode is used rather than punycode (A-labels), because it is the
+// form the PSL is written in: every internationalized suffix in the
+// list is a U-label, and none is in punycode. Requiring A-labels would
+// reject all of them. Consumers that need A-labels convert the list
+// when they load it, as golang.org/x/net/publicsuffix does.
+func (p *parser) requireCanonicalSuffixes() {
+	for _, block := range p.AllSuffixBlocks() {
+		for _, entry := range block.Entries {
+			if _, _, bad := invalidPunycode(entry.Text()); bad {
+				// Reported by requireValidPunycode, and the
+				// canonical form would be misleading.
+				continue
+			}
+			want, err := canonicalEntry(entry.Text())
+			if err != nil {
+				// Not a valid domain name at all, which other
+				// validations report more precisely.
+				continue
+			}
+			if want != entry.Text() {
+				p.addError(NonCanonicalSuffix{
+					Suffixes: block,
+					Suffix:   entry,
+					Want:     want,
+				})
+			}
+		}
+	}
+}
+
+// requireUniqueSuffixes verifies that no suffix is listed more than
+// once. Suffixes are compared in canonical form, so that case
+// variants ("Example.com" and "example.com") and the punycode and
+// Unicode forms of the same name are also duplicates.
+//
+// Identical suffixes in different sections are left to
+// requireDisjointSections.
+func (p *parser) requireUniqueSuffixes() {
+	type seenEntry struct {
+		entry   Source
+		section string
+	}
+	seen := map[string]seenEntry{}
+	for _, section := range []string{"ICANN DOMAINS", "PRIVATE DOMAINS"} {
+		for _, block := range p.File.SuffixBlocksInSection(section) {
+			for _, entry := range block.Entries {
+				key, err := canonicalEntry(entry.Text())
+				if err != nil {
+					key = strings.ToLower(entry.Text())
+				}
+				prev, ok := seen[key]
+				if !ok {
+					seen[key] = seenEntry{entry, section}
+					continue
+				}
+				if prev.section != section && prev.entry.Text() == entry.Text() {
+					continue
+				}
+				p.addError(DuplicateSuffix{
+					Suffixes: block,
+					Suffix:   entry,
+					Previous: prev.entry,
+				})
+			}
+		}
+	}
+}
+
+// reservedSuffixes are special-use domain names that can never be
+// public suffixes, because they don't resolve in the public DNS.
+//
+// "onion" (RFC 7686) is also special-use, but is deliberately listed
+// in the PSL, because it behaves like a public suffix.
+var reservedSuffixes = []string{
+	// RFC 6761
+	"example",
+	"invalid",
+	"localhost",
+	"test",
+	// RFC 6762
+	"local",
+	// RFC 8375
+	"home.arpa",
+	// RFC 9476
+	"alt",
+	// Reserved by ICANN for private use in 2024
+	"internal",
+}
+
+// rejectReservedSuffixes verifies that no suffix is a reserved
+// special-use name, or under one. See reservedSuffixes.
+func (p *parser) rejectReservedSuffixes() {
+	for _, block := range p.AllSuffixBlocks() {
+		for _, entry := range block.Entries {
+			domain := entryDomain(entry.Text())
+			for _, reserved := range reservedSuffixes {
+				if domain == reserved || strings.HasSuffix(domain, "."+reserved) {
+					p.addError(ReservedSuffix{
+						Suffixes: block,
+						Suffix:   entry,
+						Reserved: reserved,
+					})
+					break
+				}
+			}
+		}
+	}
+}
+
+// pslIDNA is the IDNA profile that defines the canonical form of PSL
+// suffixes. The profile maps names to lowercase NFC, and is
+// permissive about ASCII characters, so that the canonical form can
+// be computed independently of other validation rules.
+var pslIDNA = idna.New(idna.MapForLookup(), idna.Transitional(false), idna.StrictDomainName(false))
+
+// canonicalEntry returns the canonical form of the suffix entry. The
+// wildcard ("*.") or exception ("!") prefix of entry, if any, is
+// preserved.
+func canonicalEntry(entry string) (string, error) {
+	prefix := ""
+	if strings.HasPrefix(entry, "!") {
+		prefix = "!"
+	} else if strings.HasPrefix(entry, "*.") {
+		prefix = "*."
+	}
+	ret, err := pslIDNA.ToUnicode(entry[len(prefix):])
+	if err != nil {
+		return "", err
+	}
+	return prefix + ret, nil
+}
+
+// warnUnsortedSuffixes warns about blocks in the private section
+// whose suffixes are not sorted in canonical order (see
+// compareEntries).
+//
+// Inline comments usually annotate the suffixes that follow them, so
+// each run of suffixes between comments is checked independently.
+func (p *parser) warnUnsortedSuffixes() {
+	for _, block := range p.File.SuffixBlocksInSection("PRIVATE DOMAINS") {
+	checkBlock:
+		for _, run := range entryRuns(block.Entries) {
+			for i := 1; i < len(run); i++ {
+				if compareEntries(run[i-1].Text(), run[i].Text()) > 0 {
+					// run[:i] is sorted, so the first entry that
+					// sorts after run[i] is where it belongs.
+					before := slices.IndexFunc(run[:i], func(s Source) bool {
+						return compareEntries(s.Text(), run[i].Text()) > 0
+					})
+					p.addError(UnsortedSuffixes{
+						Suffixes: block,
+						Suffix:   run[i],
+						Before:   run[before],
+					})
+					break checkBlock
+				}
+			}
+		}
+	}
+}
+
+// warnUnsortedExceptions warns about wildcards whose exceptions are
+// not sorted in canonical order within their block.
+//
+// The order of suffixes in the private section, exceptions included,
+// is already checked by warnUnsortedSuffixes, so only blocks outside
+// of it are checked.
+func (p *parser) warnUnsortedExceptions() {
+	var curSection string
+	for _, block := range p.File.Blocks {
+		switch v := block.(type) {
+		case StartSection:
+			curSection = v.Name
+		case EndSection:
+			curSection = ""
+		case Suffixes:
+			if curSection == "PRIVATE DOMAINS" {
+				continue
+			}
+			for _, group := range wildcardExceptions(v.Entries) {
+				for i := 1; i < len(group.exceptions); i++ {
+					prev, cur := v.Entries[group.exceptions[i-1]], v.Entries[group.exceptions[i]]
+					if compareEntries(prev.Text(), cur.Text()) > 0 {
+						before := slices.IndexFunc(group.exceptions[:i], func(j int) bool {
+							return compareEntries(v.Entries[j].Text(), cur.Text()) > 0
+						})
+						p.addError(UnsortedExceptions{
+							Suffixes:  v,
+							Wildcard:  group.wildcard,
+							Exception: cur,
+							Before:    v.Entries[group.exceptions[before]],
+						})
+						break
+					}
+				}
+			}
+		}
+	}
+}
+
+// exceptionGroup is a wildcard entry of a block and the exceptions to
+// it in the same block.
+type exceptionGroup struct {
+	wildcard   Source
+	exceptions []int // indices into the block's entries, in order
+}
+
+// wildcardExceptions returns the wildcards in entries that have
+// exceptions in entries, along with those exceptions, in the order
+// the wildcards appear.
+func wildcardExceptions(entries []Source) []exceptionGroup {
+	var ret []exceptionGroup
+	for _, entry := range entries {
+		base, ok := strings.CutPrefix(entry.Text(), "*.")
+		if !ok {
+			continue
+		}
+		group := exceptionGroup{wildcard: entry}
+		for j, exc := range entries {
+			text, ok := strings.CutPrefix(exc.Text(), "!")
+			if !ok {
+				continue
+			}
+			// Malformed exceptions are reported by
+			// validateWildcardExceptions, and not sorted.
+			if label, parent, ok := strings.Cut(text, "."); ok && label != "" && parent == base {
+				group.exceptions = append(group.exceptions, j)
+			}
+		}
+		if len(group.exceptions) > 0 {
+			ret = append(ret, group)
+		}
+	}
+	return ret
+}
+
+// compareEntries compares two suffix entries in canonical PSL order,
+// which sorts domains by their labels in reverse order, starting with
+// the TLD. This groups suffixes by their parent domain: "example.com"
+// sorts before "*.example.com", which sorts before "a.example.com"
+// and "!b.example.com".
+func compareEntries(a, b string) int {
+	return slices.Compare(entrySortKey(a), entrySortKey(b))
+}
+
+func entrySortKey(entry string) []string {
+	labels := strings.Split(strings.TrimPrefix(entry, "!"), ".")
+	slices.Reverse(labels)
+	return labels
+}
+
+// entryRuns splits entries into runs of suffixes on consecutive
+// lines.
+func entryRuns(entries []Source) [][]Source {
+	var ret [][]Source
+	start := 0
+	for i := 1; i <= len(entries); i++ {
+		if i == len(entries) || entries[i].lineOffset != entries[i-1].lineOffset+1 {
+			ret = append(ret, entries[start:i])
+			start = i
+		}
+	}
+	return ret
+}
+
+// requireKnownTLDs verifies that all suffixes in the private section
+// are under a TLD listed in the ICANN section, to catch misspelled
+// TLDs.
+//
+// A TLD counts as listed if any ICANN suffix is under that TLD,
+// including wildcards like "*.ck". The ICANN section comes from
+// icannBlocks. This check is skipped if there is no ICANN section.
+func (p *parser) requireKnownTLDs() {
+	tlds := map[string]bool{}
+	for _, block := range p.icannBlocks() {
+		for _, entry := range block.Entries {
+			tlds[tld(entry.Text())] = true
+		}
+	}
+	if len(tlds) == 0 {
+		return
+	}
+
+	for _, block := range p.File.SuffixBlocksInSection("PRIVATE DOMAINS") {
+		for _, entry := range block.Entries {
+			if !tlds[tld(entry.Text())] {
+				p.addError(UnknownTLD{
+					Suffixes: block,
+					Suffix:   entry,
+				})
+			}
+		}
+	}
+}
+
+// requireDisjointSections verifies that no suffix appears in both the
+// ICANN and private sections. Private suffixes are checked against
+// the file's own ICANN section and, if there is one, that of
+// p.opts.Reference, so that a change can't move a suffix into the
+// private section by also removing it from the ICANN section, nor
+// add a private suffix to the ICANN section.
+func (p *parser) requireDisjointSections() {
+	icann := map[string]Source{}
+	if p.opts.Reference != nil {
+		for _, block := range p.opts.Reference.SuffixBlocksInSection("ICANN DOMAINS") {
+			for _, entry := range block.Entries {
+				icann[entry.Text()] = entry
+			}
+		}
+	}
+	// Prefer the file's own listing, so that the error points into
+	// the file being validated.
+	for _, block := range p.File.SuffixBlocksInSection("ICANN DOMAINS") {
+		for _, entry := range block.Entries {
+			icann[entry.Text()] = entry
+		}
+	}
+
+	for _, block := range p.File.SuffixBlocksInSection("PRIVATE DOMAINS") {
+		for _, entry := range block.Entries {
+			if icannEntry, ok := icann[entry.Text()]; ok {
+				p.addError(CrossSectionDuplicate{
+					ICANN:   icannEntry,
+					Private: entry,
+				})
+			}
+		}
+	}
+}
+
+// requireUniqueEntityNames verifies that all Suffix blocks in the
+// private section have different entity names.
+func (p *parser) requireUniqueEntityNames() {
+	seen := map[string]Suffixes{}
+	for _, block := range p.File.SuffixBlocksInSection("PRIVATE DOMAINS") {
+		if block.Entity == "" {
+			continue
+		}
+		key := strings.ToLower(strings.Join(strings.Fields(block.Entity), " "))
+		if prev, ok := seen[key]; ok {
+			p.addError(DuplicateEntityName{
+				Suffixes: block,
+				Previous: prev,
+			})
+			continue
+		}
+		seen[key] = block
+	}
+}
+
+// warnUnrelatedMaintainerEmails checks that the submitter email of
+// each private Suffix block is at the same registrable domain as one
+// of the block's suffixes, or as the block's URL. Registrable domains
+// are computed using the ICANN section from icannBlocks.
+func (p *parser) warnUnrelatedMaintainerEmails() {
+	icann := map[string]bool{}
+	for _, block := range p.icannBlocks() {
+		for _, entry := range block.Entries {
+			icann[entry.Text()] = true
+		}
+	}
+
+	for _, block := range p.File.SuffixBlocksInSection("PRIVATE DOMAINS") {
+		if block.Submitter == nil {
+			continue
+		}
+		addr := block.Submitter.Address
+		host := addr[strings.LastIndexByte(addr, '@')+1:]
+		if sourceIsExempted(freeEmailProviders, strings.ToLower(host)) {
+			continue
+		}
+		want := registrableDomain(icann, host)
+
+		related := block.URL != nil && registrableDomain(icann, block.URL.Hostname()) == want
+		for _, entry := range block.Entries {
+			if related {
+				break
+			}
+			domain := strings.TrimPrefix(strings.TrimPrefix(entry.Text(), "!"), "*.")
+			related = registrableDomain(icann, domain) == want
+		}
+
+		if !related {
+			p.addError(UnrelatedMaintainerEmail{
+				Suffixes: block,
+			})
+		}
+	}
+}
+
+// registrableDomain returns the public suffix of host according to the
+// given ICANN suffixes, plus one more label. If no ICANN suffix
+// matches, the TLD of host is used as its public suffix.
+func registrableDomain(icann map[string]bool, host string) string {
+	host = strings.ToLower(host)
+	if u, err := pslIDNA.ToUnicode(host); err == nil {
+		host = u
+	}
+
+	ret := host
+	for cur := host; cur != ""; cur = parentDomain(cur) {
+		if icann[cur] || icann["*."+parentDomain(cur)] || !strings.Contains(cur, ".") {
+			return ret
+		}
+		ret = cur
+	}
+	return ret
+}
+
+// warnFreeEmailMaintainers warns about private Suffix blocks with
+// more than one suffix whose contact email address is at one of the
+// freeEmailProviders. Organizations that own several domains usually
+// have their own email domain, so a free address is worth a second
+// look to confirm the submitter speaks for the entity. Entities in
+// freeEmailMaintainerEntities are not reported.
+func (p *parser) warnFreeEmailMaintainers() {
+	for _, block := range p.File.SuffixBlocksInSection("PRIVATE DOMAINS") {
+		if block.Submitter == nil || len(block.Entries) < 2 || slices.Contains(freeEmailMaintainerEntities, block.Entity) {
+			continue
+		}
+		addr := block.Submitter.Address
+		host := strings.ToLower(addr[strings.LastIndexByte(addr, '@')+1:])
+		if sourceIsExempted(freeEmailProviders, host) {
+			p.addError(FreeEmailMaintainer{
+				Suffixes: block,
+			})
+		}
+	}
+}
+
+// validateWildcardExceptions checks that every exception adds a
+// single valid label to the base domain of the closest wildcard it is
+// an exception to, and that every exception has such a wildcard at
+// all. Exceptions with empty labels, including a bare "!", are
+// rejected before looking for a wildcard.
+func (p *parser) validateWildcardExceptions() {
+	wildcards := map[string]Source{}
+	for _, block := range p.AllSuffixBlocks() {
+		for _, entry := range block.Entries {
+			if base, ok := strings.CutPrefix(entry.Text(), "*."); ok {
+				wildcards[base] = entry
+			}
+		}
+	}
+
+	for _, block := range p.AllSuffixBlocks() {
+		for _, entry := range block.Entries {
+			exc, ok := strings.CutPrefix(entry.Text(), "!")
+			if !ok {
+				continue
+			}
+			if hasEmptyLabel(exc) {
+				p.addError(EmptyWildcardException{
+					Suffixes: block,
+					Suffix:   entry,
+				})
+				continue
+			}
+
+			// Find the closest wildcard base domain that exc is
+			// under. The exception itself is checked first, to catch
+			// exceptions that add no label at all.
+			base := exc
+			for base != "" {
+				if _, ok := wildcards[base]; ok {
+					break
+				}
+				base = parentDomain(base)
+			}
+			if base == "" {
+				p.addError(OrphanedException{
+					Suffixes: block,
+					Suffix:   entry,
+				})
+				continue
+			}
+
+			label := strings.TrimSuffix(strings.TrimSuffix(exc, base), ".")
+			if label == "" || strings.Contains(label, ".") || !isHostname(label) {
+				p.addError(InvalidWildcardException{
+					Suffixes: block,
+					Suffix:   entry,
+					Wildcard: wildcards[base],
+					Label:    label,
+				})
+			}
+		}
+	}
+}
+
+// requireValidLabels checks that every label of every suffix is made
+// of characters that are valid in a hostname. The leading "!" of
+// exceptions and "*" label of wildcards are not checked. Empty labels
+// are left to requireNonEmptyLabels.
+func (p *parser) requireValidLabels() {
+	for _, block := range p.AllSuffixBlocks() {
+		for _, entry := range block.Entries {
+			domain := strings.TrimPrefix(strings.TrimPrefix(entry.Text(), "!"), "*.")
+			for _, label := range strings.Split(domain, ".") {
+				if label != "" && !isLabel(label) {
+					p.addError(InvalidSuffixLabel{
+						Suffixes: block,
+						Suffix:   entry,
+						Label:    label,
+					})
+					break
+				}
+			}
+		}
+	}
+}
+
+// requireNonEmptyLabels checks that no suffix has an empty label,
+// from a leading or trailing dot or two consecutive dots. Exceptions
+// are left to validateWildcardExceptions.
+func (p *parser) requireNonEmptyLabels() {
+	for _, block := range p.AllSuffixBlocks() {
+		for _, entry := range block.Entries {
+			if strings.HasPrefix(entry.Text(), "!") {
+				continue
+			}
+			if hasEmptyLabel(strings.TrimPrefix(entry.Text(), "*.")) {
+				p.addError(EmptyLabel{
+					Suffixes: block,
+					Suffix:   entry,
+				})
+			}
+		}
+	}
+}
+
+// requireNoWildcardOverlap checks that no section lists both a
+// wildcard and its base domain as a plain suffix, for example both
+// "*.example.com" and "example.com".
+func (p *parser) requireNoWildcardOverlap() {
+	for _, section := range []string{"ICANN DOMAINS", "PRIVATE DOMAINS"} {
+		blocks := p.File.SuffixBlocksInSection(section)
+
+		plain := map[string]Source{}
+		for _, block := range blocks {
+			for _, entry := range block.Entries {
+				if isPlainSuffix(entry.Text()) {
+					plain[entry.Text()] = entry
+				}
+			}
+		}
+
+		for _, block := range blocks {
+			for _, entry := range block.Entries {
+				base, ok := strings.CutPrefix(entry.Text(), "*.")
+				if !ok {
+					continue
+				}
+				if suffix, ok := plain[base]; ok {
+					p.addError(SuffixWildcardOverlap{
+						Suffix:   suffix,
+						Wildcard: entry,
+					})
+				}
+			}
+		}
+	}
+}
+
+// requireNoWildcardCoveredSuffixes checks that no block lists a
+// suffix that a wildcard in the same block already covers, for
+// example both "*.example.com" and "foo.example.com". A suffix that
+// the block also has an exception for ("!foo.example.com") is not
+// covered, since the exception removes it from the wildcard.
+func (p *parser) requireNoWildcardCoveredSuffixes() {
+	for _, block := range p.AllSuffixBlocks() {
+		wildcards := map[string]Source{}
+		exceptions := map[string]bool{}
+		for _, entry := range block.Entries {
+			if base, ok := strings.CutPrefix(entry.Text(), "*."); ok {
+				wildcards[base] = entry
+			} else if exc, ok := strings.CutPrefix(entry.Text(), "!"); ok {
+				exceptions[exc] = true
+			}
+		}
+		if len(wildcards) == 0 {
+			continue
+		}
+
+		for _, entry := range block.Entries {
+			if !isPlainSuffix(entry.Text()) || exceptions[entry.Text()] {
+				continue
+			}
+			if wildcard, ok := wildcards[parentDomain(entry.Text())]; ok {
+				p.addError(SuffixCoveredByWildcard{
+					Suffixes: block,
+					Suffix:   entry,
+					Wildcard: wildcard,
+				})
+			}
+		}
+	}
+}
+
+// requireUniqueMaintainers checks that no email address appears more
+// than once in the header of a Suffix block. Addresses are compared
+// case-insensitively on the host part.
+func (p *parser) requireUniqueMaintainers() {
+	for _, block := range p.AllSuffixBlocks() {
+		seen := map[string]bool{}
+		for _, line := range block.Header {
+			for _, addr := range emailAddresses(line.Text()) {
+				local, host, _ := strings.Cut(addr, "@")
+				key := local + "@" + strings.ToLower(host)
+				if seen[key] {
+					p.addError(DuplicateMaintainer{
+						Suffixes: block,
+						Line:     line,
+						Address:  addr,
+					})
+					continue
+				}
+				seen[key] = true
+			}
+		}
+	}
+}
+
+// emailAddresses returns all the email addresses in line, which can be
+// bare or enclosed in angle brackets.
+func emailAddresses(line string) []string {
+	isSep := func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune("<>,;()", r)
+	}
+	var ret []string
+	for _, word := range strings.FieldsFunc(line, isSep) {
+		if !strings.Contains(word, "@") {
+			continue
+		}
+		if addr, err := mail.ParseAddress(word); err == nil {
+			ret = append(ret, addr.Address)
+		}
+	}
+	return ret
+}
+
+// requireDNSLengthLimits checks that every suffix fits within the DNS
+// limits of 63 octets per label and 253 octets in total, once
+// converted to punycode. The "*" label of wildcards is counted, since
+// it stands for at least one real label.
+func (p *parser) requireDNSLengthLimits() {
+	const (
+		maxLabel  = 63
+		maxDomain = 253
+	)
+	for _, block := range p.AllSuffixBlocks() {
+		for _, entry := range block.Entries {
+			ascii, err := pslIDNA.ToASCII(strings.TrimPrefix(entry.Text(), "!"))
+			if err != nil {
+				// Not a valid domain name, which other validations
+				// report.
+				continue
+			}
+			if len(ascii) > maxDomain {
+				p.addError(SuffixTooLong{
+					Suffixes: block,
+					Suffix:   entry,
+					Length:   len(ascii),
+				})
+			}
+			for _, label := range strings.Split(ascii, ".") {
+				if len(label) > maxLabel {
+					p.addError(LabelTooLong{
+						Suffixes: block,
+						Suffix:   entry,
+						Label:    label,
+					})
+				}
+			}
+		}
+	}
+}
+
+// requireValidPunycode checks that every punycode ("xn--") label of
+// every suffix decodes to a valid Unicode label.
+func (p *parser) requireValidPunycode() {
+	for _, block := range p.AllSuffixBlocks() {
+		for _, entry := range block.Entries {
+			if label, decoded, bad := invalidPunycode(entry.Text()); bad {
+				p.addError(InvalidPunycode{
+					Suffixes: block,
+					Suffix:   entry,
+					Label:    label,
+					Decoded:  decoded,
+				})
+			}
+		}
+	}
+}
+
+// requireValidHyphenation checks that no label of any suffix has
+// hyphens in both the third and fourth positions, unless it is a
+// punycode ("xn--") label. RFC 5891 section 4.2.3.1 reserves that
+// form for ACE prefixes, and punycode labels are checked by
+// requireValidPunycode instead.
+func (p *parser) requireValidHyphenation() {
+	for _, block := range p.AllSuffixBlocks() {
+		for _, entry := range block.Entries {
+			domain := strings.TrimPrefix(strings.TrimPrefix(entry.Text(), "!"), "*.")
+			for _, label := range strings.Split(domain, ".") {
+				runes := []rune(label)
+				if len(runes) >= 4 && runes[2] == '-' && runes[3] == '-' && !strings.HasPrefix(strings.ToLower(label), "xn--") {
+					p.addError(InvalidHyphenation{
+						Suffixes: block,
+						Suffix:   entry,
+						Label:    label,
+					})
+					break
+				}
+			}
+		}
+	}
+}
+
+// invalidPunycode returns the first punycode label of entry that does
+// not decode to a valid Unicode label, and what it decodes to, if
+// anything. A label is invalid if it fails to decode, decodes to
+// something that IDNA rejects, or doesn't encode back to the same
+// label, for example because it decodes to plain ASCII.
+func invalidPunycode(entry string) (label, decoded string, bad bool) {
+	for _, label := range strings.Split(entry, ".") {
+		lower := strings.ToLower(label)
+		if !strings.HasPrefix(lower, "xn--") {
+			continue
+		}
+		decoded, err := idna.Punycode.ToUnicode(lower)
+		if err != nil {
+			return label, "", true
+		}
+		if _, err := pslIDNA.ToUnicode(lower); err != nil {
+			return label, decoded, true
+		}
+		if reencoded, err := pslIDNA.ToASCII(decoded); err != nil || reencoded != lower {
+			return label, decoded, true
+		}
+	}
+	return "", "", false
+}
+
+// requireNoPrivateTLDs verifies that the private section has no
+// single-label suffixes, either plain ("foo") or wildcard ("*.foo").
+// TLDs belong in the ICANN section, so one appearing in the private
+// section is almost always a mistake.
+func (p *parser) requireNoPrivateTLDs() {
+	for _, block := range p.File.SuffixBlocksInSection("PRIVATE DOMAINS") {
+		for _, entry := range block.Entries {
+			if strings.HasPrefix(entry.Text(), "!") {
+				continue
+			}
+			if !strings.Contains(strings.TrimPrefix(entry.Text(), "*."), ".") {
+				p.addError(TLDInPrivateSection{
+					Suffixes: block,
+					Suffix:   entry,
+				})
+			}
+		}
+	}
+}
+
+// warnUnknownEmailTLDs warns about contact email addresses whose
+// domain is not under a TLD listed in the ICANN section, to catch
+// typos like "example.con". This is only a warning, because the
+// ICANN section can lag behind newly delegated TLDs. Like
+// requireKnownTLDs, it is skipped for files that have no ICANN
+// section.
+func (p *parser) warnUnknownEmailTLDs() {
+	tlds := map[string]bool{}
+	for _, block := range p.icannBlocks() {
+		for _, entry := range block.Entries {
+			tlds[tld(entry.Text())] = true
+		}
+	}
+	if len(tlds) == 0 {
+		return
+	}
+
+	for _, block := range p.AllSuffixBlocks() {
+		if block.Submitter == nil {
+			continue
+		}
+		addr := block.Submitter.Address
+		host := strings.ToLower(addr[strings.LastIndexByte(addr, '@')+1:])
+		if u, err := pslIDNA.ToUnicode(host); err == nil {
+			host = u
+		}
+		if !tlds[tld(host)] {
+			p.addError(InvalidEmailTLD{
+				Suffixes: block,
+			})
+		}
+	}
+}
+
+var (
+	commentURLOrEmail = regexp.MustCompile(`(?i)[a-z][a-z0-9+.-]*://\S*|\S+@\S+`)
+	commentDomain     = regexp.MustCompile(`(\*\.)?[\pL\pN]([\pL\pN-]*[\pL\pN])?(\.[\pL\pN]([\pL\pN-]*[\pL\pN])?)+`)
+)
+
+// warnCommentDomainMismatches warns about domains mentioned in the
+// comments of private section blocks that have nothing to do with the
+// block's suffixes, which usually means that the comment wasn't
+// updated when the suffixes changed.
+//
+// This is a heuristic, so it is deliberately lenient. URLs and email
+// addresses are ignored, as are domains under a TLD that isn't in the
+// ICANN section (to skip things like file names and version numbers).
+// A domain is only reported if it is not one of the block's suffixes,
+// not a parent or child of one, and not related to the block's entity
+// name, URL or contact email.
+func (p *parser) warnCommentDomainMismatches() {
+	tlds := map[string]bool{}
+	for _, block := range p.icannBlocks() {
+		for _, entry := range block.Entries {
+			tlds[tld(entry.Text())] = true
+		}
+	}
+	if len(tlds) == 0 {
+		return
+	}
+
+	for _, block := range p.File.SuffixBlocksInSection("PRIVATE DOMAINS") {
+		known := map[string]bool{}
+		for _, entry := range block.Entries {
+			known[entryDomain(entry.Text())] = true
+		}
+		for _, domain := range commentDomain.FindAllString(block.Entity, -1) {
+			known[strings.ToLower(domain)] = true
+		}
+		if block.URL != nil {
+			known[strings.ToLower(block.URL.Hostname())] = true
+		}
+		if block.Submitter != nil {
+			addr := block.Submitter.Address
+			known[strings.ToLower(addr[strings.LastIndexByte(addr, '@')+1:])] = true
+		}
+		related := func(domain string) bool {
+			for k := range known {
+				if domain == k || strings.HasSuffix(domain, "."+k) || strings.HasSuffix(k, "."+domain) {
+					return true
+				}
+			}
+			return false
+		}
+
+		var mismatched []string
+		seen := map[string]bool{}
+		for _, line := range append(slices.Clone(block.Header), block.InlineComments...) {
+			text := commentURLOrEmail.ReplaceAllString(line.Text(), " ")
+			for _, match := range commentDomain.FindAllString(text, -1) {
+				domain := strings.ToLower(strings.TrimPrefix(match, "*."))
+				if seen[domain] || !tlds[tld(domain)] || related(domain) {
+					continue
+				}
+				seen[domain] = true
+				mismatched = append(mismatched, domain)
+			}
+		}
+		if len(mismatched) > 0 {
+			p.addError(CommentDomainMismatch{
+				Suffixes: block,
+				Domains:  mismatched,
+			})
+		}
+	}
+}
+
+// warnSharedMaintainers warns about contact email addresses that are
+// used by several private Suffix blocks with different entity names.
+// Those blocks may belong to a single organization that should be
+// merged into one block, or one of the names may be a typo.
+//
+// Addresses are compared like in requireUniqueMaintainers, and entity
+// names like in requireUniqueEntityNames.
+func (p *parser) warnSharedMaintainers() {
+	var order []string
+	byAddr := map[string][]Suffixes{}
+	names := map[string]map[string]bool{}
+	for _, block := range p.File.SuffixBlocksInSection("PRIVATE DOMAINS") {
+		if block.Submitter == nil || block.Entity == "" {
+			continue
+		}
+		local, host, _ := strings.Cut(block.Submitter.Address, "@")
+		addr := local + "@" + strings.ToLower(host)
+		name := strings.ToLower(strings.Join(strings.Fields(block.Entity), " "))
+		if names[addr] == nil {
+			order = append(order, addr)
+			names[addr] = map[string]bool{}
+		}
+		if !names[addr][name] {
+			names[addr][name] = true
+			byAddr[addr] = append(byAddr[addr], block)
+		}
+	}
+
+	for _, addr := range order {
+		if blocks := byAddr[addr]; len(blocks) > 1 {
+			p.addError(SharedMaintainer{
+				Address:  blocks[0].Submitter.Address,
+				Entities: blocks,
+			})
+		}
+	}
+}
+
+// warnMergedEntities warns about private Suffix blocks with an inline
+// comment that looks like the header of another block: an entity name
+// followed by a URL or email address, or a "Submitted by" line. This
+// usually means the blank line between two blocks is missing, so the
+// suffixes of the second block are attributed to the first.
+//
+// ICANN blocks are skipped, because their inline comments often link
+// to the policies of groups of second-level domains. Each comment is
+// reported at most once.
+func (p *parser) warnMergedEntities() {
+	for _, block := range p.File.SuffixBlocksInSection("PRIVATE DOMAINS") {
+		for _, comment := range block.InlineComments {
+			for _, line := range comment.lineSources() {
+				if looksLikeHeader(line.Text()) {
+					p.addError(PossiblyMergedEntities{
+						Suffixes: block,
+						Line:     line,
+					})
+					break
+				}
+			}
+		}
+	}
+}
+
+// requireEntitySuffixes verifies that the private section has no
+// top-level comments that look like the header of a block of
+// suffixes (see looksLikeHeader). Such a comment is the header of an
+// entity without any suffixes, usually because a change removed all
+// of them, or added a header but forgot the suffixes.
+func (p *parser) requireEntitySuffixes() {
+	var curSection string
+	for _, block := range p.File.Blocks {
+		switch v := block.(type) {
+		case StartSection:
+			curSection = v.Name
+		case EndSection:
+			curSection = ""
+		case Comment:
+			if curSection != "PRIVATE DOMAINS" {
+				continue
+			}
+			for _, line := range v.lineSources() {
+				if looksLikeHeader(line.Text()) {
+					p.addError(EmptyEntity{
+						Comment: v,
+					})
+					break
+				}
+			}
+		}
+	}
+}
+
+// looksLikeHeader reports whether the comment line text looks like
+// part of the header of a block of suffixes: an entity name followed
+// by a URL or email address, or a "Submitted by" line.
+func looksLikeHeader(text string) bool {
+	text = strings.TrimSpace(strings.TrimPrefix(text, "//"))
+	name, url, submitter := splitNameish(text)
+	return (name != "" && (url != nil || submitter != nil)) || getSubmitter(text) != nil
+}
+
+// warnMisfiledICANNSuffixes warns about small ICANN blocks that look
+// like they belong in the private section: blocks that don't list
+// any TLD themselves, and whose suffixes are all under TLDs that
+// other ICANN blocks list. For example, a "brand.com" block next to
+// the "com" block.
+//
+// The converse, TLDs in the private section, is reported by
+// requireNoPrivateTLDs.
+func (p *parser) warnMisfiledICANNSuffixes() {
+	maxEntries := p.opts.maxMisfiledICANNEntries()
+	if maxEntries == 0 {
+		return
+	}
+
+	blocks := p.File.SuffixBlocksInSection("ICANN DOMAINS")
+	owners := map[string]Suffixes{}
+	for _, block := range blocks {
+		for _, entry := range block.Entries {
+			if t := strings.TrimPrefix(entry.Text(), "*."); !strings.Contains(t, ".") {
+				owners[t] = block
+			}
+		}
+	}
+
+	for _, block := range blocks {
+		if len(block.Entries) == 0 || len(block.Entries) > maxEntries {
+			continue
+		}
+		var owner Suffixes
+		misfiled := true
+		for _, entry := range block.Entries {
+			o, ok := owners[tld(entry.Text())]
+			if !ok || o.lineOffset == block.lineOffset {
+				misfiled = false
+				break
+			}
+			owner = o
+		}
+		if misfiled {
+			p.addError(SuffixInWrongSection{
+				Suffixes: block,
+				Owner:    owner,
+			})
+		}
+	}
+}
+
+// hasEmptyLabel reports whether domain is empty, or has a label that
+// is empty or only whitespace.
+func hasEmptyLabel(domain string) bool {
+	for _, label := range strings.Split(domain, ".") {
+		if strings.TrimSpace(label) == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// tld returns the last label of a suffix entry.
+func tld(entry string) string {
+	return entry[strings.LastIndexByte(entry, '.')+1:]
+}
+
+// isPlainSuffix reports whether entry is a plain domain suffix, as
+// opposed to a wildcard ("*.") or exception ("!") rule.
+func isPlainSuffix(entry string) bool {
+	return !strings.HasPrefix(entry, "*.") && !strings.HasPrefix(entry, "!")
+}
+
+// parentDomain returns domain with its first label removed, or the
+// empty string if domain has a single label.
+func parentDomain(domain string) string {
+	_, parent, _ := strings.Cut(domain, ".")
+	return parent
+}
+
+// entryDomain returns the domain of the suffix entry in lowercase,
+// without its wildcard ("*.") or exception ("!") prefix, if any.
+func entryDomain(entry string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(entry, "!"), "*."))
+}
+
+// isHostname reports whether s is a syntactically valid DNS hostname:
+// one or more dot-separated labels of 1 to 63 letters, digits and
+// hyphens, with no label starting or ending with a hyphen. Non-ASCII
+// letters are accepted, to allow for internationalized domain names.
+func isHostname(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, label := range strings.Split(s, ".") {
+		if label == "" || len(label) > 63 || !isLabel(label) {
+			return false
+		}
+	}
+	return true
+}
+
+// isLabel reports whether label consists only of letters, digits and
+// hyphens, and doesn't start or end with a hyphen. Like isHostname,
+// non-ASCII letters are accepted. Punycode labels ("xn--...") pass
+// this check, since they are made of ASCII letters, digits and
+// hyphens.
+func isLabel(label string) bool {
+	if strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+		return false
+	}
+	for _, r := range label {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-':
+		case r > unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)):
+		default:
+			return false
+		}
+	}
+	return true
+}